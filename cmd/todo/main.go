@@ -1,20 +1,45 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"log/slog"
 	"net/http"
 	"os"
+	"todo/internal/account"
 	"todo/internal/config"
+	"todo/internal/handlers/admin"
+	"todo/internal/handlers/calendar"
+	"todo/internal/handlers/items"
 	"todo/internal/handlers/notes"
+	"todo/internal/handlers/notifications"
+	"todo/internal/handlers/passwords"
+	"todo/internal/handlers/projects"
+	reminderhandlers "todo/internal/handlers/reminders"
+	"todo/internal/handlers/revisions"
+	"todo/internal/handlers/sessions"
+	"todo/internal/handlers/sso"
+	"todo/internal/handlers/tags"
+	"todo/internal/handlers/tokens"
+	"todo/internal/handlers/trash"
+	"todo/internal/handlers/twofactor"
 	"todo/internal/handlers/users"
+	"todo/internal/handlers/wellknown"
+	"todo/internal/lockout"
+	"todo/internal/mfa"
 	appmiddleware "todo/internal/middleware"
+	"todo/internal/models"
+	"todo/internal/oidc"
+	"todo/internal/purger"
+	"todo/internal/reminders"
+	"todo/internal/revocation"
 	"todo/internal/storage/postgres"
 	"todo/pkg/auth"
 	"todo/pkg/logger"
 	"todo/pkg/logger/sl"
+	"todo/pkg/mailer"
 )
 
 func main() {
@@ -23,48 +48,323 @@ func main() {
 	log := logger.New(cfg.Env)
 	log.Debug("debug messages are enabled")
 
-	manager, err := auth.NewManager()
+	keyCfgs := make([]auth.KeyConfig, 0, len(cfg.JWT.Keys))
+	for _, k := range cfg.JWT.Keys {
+		keyCfgs = append(keyCfgs, auth.KeyConfig{
+			ID:             k.ID,
+			Algorithm:      k.Algorithm,
+			PrivateKeyPath: k.PrivateKeyPath,
+			PublicKeyPath:  k.PublicKeyPath,
+			SignFrom:       k.SignFrom,
+			RetireAt:       k.RetireAt,
+		})
+	}
+
+	manager, err := auth.NewManager(keyCfgs)
 	if err != nil {
 		log.Error("manager initialization failed", sl.Err(err))
 		os.Exit(1)
 	}
 	log.Info("manager initialized")
 
-	storage, err := postgres.New(cfg.ConnectionString, cfg.StandardQueryTimeout)
+	storage, err := postgres.New(cfg.ConnectionString, cfg.StandardQueryTimeout, cfg.MaxNoteRevisions)
 	if err != nil {
 		log.Error("storage initialization failed", sl.Err(err))
 		os.Exit(1)
 	}
 	log.Info("storage initialized")
 
+	revocationChecker := revocation.NewChecker(storage, cfg.RevocationCacheTTL)
+	var mail mailer.Mailer
+	switch cfg.Mailer.Driver {
+	case "smtp":
+		mail = mailer.NewSMTP(cfg.Mailer.SMTP.Host, cfg.Mailer.SMTP.Port, cfg.Mailer.SMTP.Username, cfg.Mailer.SMTP.Password, cfg.Mailer.From)
+	case "file":
+		mail = mailer.NewFile(log, cfg.Mailer.Dir, cfg.Mailer.From)
+	default:
+		log.Error("unknown mailer driver", slog.String("driver", cfg.Mailer.Driver))
+		os.Exit(1)
+	}
+	log.Info("mailer initialized", slog.String("driver", cfg.Mailer.Driver))
+
+	mfaService := mfa.NewService(storage, manager, cfg.TOTPIssuer)
+	accountService := account.NewService(storage, manager, manager, revocationChecker, mail, cfg.AppURL, cfg.EmailVerificationTTL, cfg.PasswordResetTTL)
+	guard := lockout.NewGuard(log, storage, lockout.Config{
+		MaxAttempts:   cfg.Lockout.MaxAttempts,
+		IPMaxAttempts: cfg.Lockout.IPMaxAttempts,
+		BaseDelay:     cfg.Lockout.BaseDelay,
+		MaxDelay:      cfg.Lockout.MaxDelay,
+		CacheTTL:      cfg.Lockout.CacheTTL,
+	})
+	go purger.NewPurger(log, storage, cfg.Trash.Retention, cfg.Trash.PurgeInterval).Run(context.Background())
+	log.Info("trash purger started", slog.Duration("retention", cfg.Trash.Retention))
+	webhook := reminders.NewWebhook(cfg.Reminders.DeliveryTimeout, cfg.Reminders.WebhookHTTPSOnly)
+	go reminders.NewScheduler(log, storage, map[string]reminders.Channel{
+		models.ReminderChannelWebhook: webhook,
+		models.ReminderChannelEmail:   reminders.NewEmail(mail),
+		models.ReminderChannelInApp:   reminders.NewInApp(),
+	}, reminders.Config{
+		PollInterval:    cfg.Reminders.PollInterval,
+		DeliveryTimeout: cfg.Reminders.DeliveryTimeout,
+		MaxAttempts:     cfg.Reminders.MaxAttempts,
+		BaseDelay:       cfg.Reminders.BaseDelay,
+		MaxDelay:        cfg.Reminders.MaxDelay,
+	}).Run(context.Background())
+	log.Info("reminder scheduler started", slog.Duration("poll_interval", cfg.Reminders.PollInterval))
+
+	authenticate := appmiddleware.Authenticate(log, manager, revocationChecker, manager, storage, storage)
+
 	router := chi.NewRouter()
 
 	router.Use(
 		middleware.RequestID,
+		// the calendar feed is authenticated with ?token=, which must not end up in the log
+		appmiddleware.RedactQuery("token"),
 		middleware.Logger,
 		middleware.Recoverer,
 	)
 
-	router.Post("/users", users.NewSaveUserHandler(log, storage, manager, cfg.AccessTokenTTl))
+	router.Get("/.well-known/jwks.json", wellknown.NewGetJWKSHandler(log, manager))
+
+	router.Post("/users", users.NewSaveUserHandler(log, storage, manager, manager, manager, storage, accountService, guard, cfg.AccessTokenTTl, cfg.RefreshTokenTTL))
+	router.Post("/users/email/verify", users.NewVerifyEmailHandler(log, accountService))
+
+	router.With(
+		authenticate,
+		appmiddleware.Authorize(log),
+		appmiddleware.RequireScope(log, auth.ScopeAccount),
+	).Post("/users/{id}/email/verification", users.NewSaveEmailVerificationHandler(log, accountService))
+
+	router.Route(
+		"/password",
+		func(r chi.Router) {
+			r.Post("/forgot", passwords.NewForgotPasswordHandler(log, accountService))
+			r.Post("/reset", passwords.NewResetPasswordHandler(log, accountService))
+		},
+	)
+
+	router.Route(
+		"/sessions",
+		func(r chi.Router) {
+			r.Post("/", sessions.NewSaveSessionHandler(log, storage, manager, manager, manager, storage, manager, guard, cfg.AccessTokenTTl, cfg.RefreshTokenTTL, cfg.MFATokenTTL))
+			r.Post("/mfa", sessions.NewVerifySessionHandler(log, manager, mfaService, manager, manager, storage, guard, cfg.AccessTokenTTl, cfg.RefreshTokenTTL))
+			r.Post("/refresh", sessions.NewRefreshSessionHandler(log, storage, manager, manager, manager, cfg.AccessTokenTTl, cfg.RefreshTokenTTL))
+
+			r.Group(func(r chi.Router) {
+				r.Use(
+					authenticate,
+					appmiddleware.RequireScope(log, auth.ScopeSessions),
+				)
+
+				r.Post("/logout", sessions.NewDeleteSessionHandler(log, revocationChecker, storage, manager))
+				r.Post("/logout-all", sessions.NewDeleteSessionsHandler(log, revocationChecker))
+			})
+		},
+	)
+
+	if cfg.OIDC.Issuer != "" {
+		provider, err := oidc.NewProvider(context.Background(), cfg.OIDC.Issuer, cfg.OIDC.ClientID, cfg.OIDC.ClientSecret, cfg.OIDC.RedirectURL)
+		if err != nil {
+			log.Error("oidc provider initialization failed", sl.Err(err))
+			os.Exit(1)
+		}
+		log.Info("oidc provider initialized", slog.String("issuer", cfg.OIDC.Issuer))
+
+		router.Route(
+			"/oidc",
+			func(r chi.Router) {
+				r.Get("/login", sso.NewLoginHandler(log, provider, storage, cfg.OIDC.StateTTL))
+				r.Get("/callback", sso.NewCallbackHandler(log, storage, provider, storage, manager, manager, storage, manager, cfg.AccessTokenTTl, cfg.RefreshTokenTTL, cfg.MFATokenTTL))
+			},
+		)
+	}
 
 	router.Route(
 		"/users/{id}/notes",
 		func(r chi.Router) {
-			r.Use(appmiddleware.Authorize(log, manager))
+			r.Use(
+				authenticate,
+				appmiddleware.Authorize(log),
+			)
 
-			r.Post("/", notes.NewSaveNoteHandler(log, storage))
-			r.Get("/", notes.NewGetNotesHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/", notes.NewSaveNoteHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/", notes.NewGetNotesHandler(log, storage))
 		},
 	)
 
+	router.With(
+		authenticate,
+		appmiddleware.Authorize(log),
+		appmiddleware.RequireScope(log, auth.ScopeNotesWrite),
+	).Post("/users/{id}/notes:batch", notes.NewBatchNotesHandler(log, storage))
+
+	// a route of its own, so that "search" isn't taken for a {note_id}
+	router.With(
+		authenticate,
+		appmiddleware.Authorize(log),
+		appmiddleware.RequireScope(log, auth.ScopeNotesRead),
+	).Get("/users/{id}/notes/search", notes.NewSearchNotesHandler(log, storage))
+
 	router.Route(
 		"/users/{id}/notes/{note_id}",
 		func(r chi.Router) {
-			r.Use(appmiddleware.Authorize(log, manager))
+			r.Use(
+				authenticate,
+				appmiddleware.Authorize(log),
+			)
+
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/", notes.NewGetNoteHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Put("/", notes.NewUpdateNoteHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Patch("/", notes.NewPatchNoteHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Delete("/", notes.NewDeleteNoteHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/complete", notes.NewCompleteNoteHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Put("/project", notes.NewMoveNoteHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/revisions", revisions.NewGetRevisionsHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/revisions/diff", revisions.NewDiffRevisionsHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/revisions/{revision}", revisions.NewGetRevisionHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/revisions/{revision}/restore", revisions.NewRestoreRevisionHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/items", items.NewSaveItemHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/items", items.NewGetItemsHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Put("/items/order", items.NewReorderItemsHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Put("/items/{item_id}", items.NewUpdateItemHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Delete("/items/{item_id}", items.NewDeleteItemHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/reminders", reminderhandlers.NewSaveReminderHandler(log, storage, webhook))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/reminders", reminderhandlers.NewGetRemindersHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Delete("/reminders/{reminder_id}", reminderhandlers.NewDeleteReminderHandler(log, storage))
+		},
+	)
+
+	router.Route(
+		"/users/{id}/trash",
+		func(r chi.Router) {
+			r.Use(
+				authenticate,
+				appmiddleware.Authorize(log),
+			)
+
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/", trash.NewGetTrashHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/{note_id}/restore", trash.NewRestoreNoteHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Delete("/{note_id}", trash.NewPurgeNoteHandler(log, storage))
+		},
+	)
+
+	router.Route(
+		"/users/{id}/notifications",
+		func(r chi.Router) {
+			r.Use(
+				authenticate,
+				appmiddleware.Authorize(log),
+			)
+
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/", notifications.NewGetNotificationsHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/{notification_id}/read", notifications.NewReadNotificationHandler(log, storage))
+		},
+	)
+
+	router.Route(
+		"/users/{id}/projects",
+		func(r chi.Router) {
+			r.Use(
+				authenticate,
+				appmiddleware.Authorize(log),
+			)
+
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/", projects.NewSaveProjectHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/", projects.NewGetProjectsHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/{project_id}", projects.NewGetProjectHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Put("/{project_id}", projects.NewUpdateProjectHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Delete("/{project_id}", projects.NewDeleteProjectHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/{project_id}/notes", notes.NewGetNotesHandler(log, storage))
+		},
+	)
+
+	router.Route(
+		"/users/{id}/tags",
+		func(r chi.Router) {
+			r.Use(
+				authenticate,
+				appmiddleware.Authorize(log),
+			)
+
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/", tags.NewSaveTagHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesRead)).Get("/", tags.NewGetTagsHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Put("/{tag_id}", tags.NewUpdateTagHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Delete("/{tag_id}", tags.NewDeleteTagHandler(log, storage))
+		},
+	)
+
+	router.Route(
+		"/users/{id}/tokens",
+		func(r chi.Router) {
+			r.Use(
+				authenticate,
+				appmiddleware.Authorize(log),
+				appmiddleware.RequireScope(log, auth.ScopeTokens),
+			)
+
+			r.Post("/", tokens.NewSaveTokenHandler(log, storage, manager))
+			r.Get("/", tokens.NewGetTokensHandler(log, storage))
+			r.Delete("/{token_id}", tokens.NewDeleteTokenHandler(log, storage))
+		},
+	)
+
+	// calendar apps can't send a bearer token, the feed is authenticated by its ?token=
+	router.Get("/users/{id}/calendar.ics", calendar.NewGetFeedHandler(log, storage, manager))
+
+	router.Route(
+		"/users/{id}/calendar",
+		func(r chi.Router) {
+			r.Use(authenticate, appmiddleware.Authorize(log))
+
+			r.With(appmiddleware.RequireScope(log, auth.ScopeTokens)).Post("/token", calendar.NewSaveTokenHandler(log, storage, manager, cfg.AppURL))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeTokens)).Delete("/token", calendar.NewDeleteTokenHandler(log, storage))
+			r.With(appmiddleware.RequireScope(log, auth.ScopeNotesWrite)).Post("/import", calendar.NewImportCalendarHandler(log, storage))
+		},
+	)
+
+	router.Route(
+		"/users/{id}/mfa/totp",
+		func(r chi.Router) {
+			r.Use(
+				authenticate,
+				appmiddleware.Authorize(log),
+				appmiddleware.RequireScope(log, auth.ScopeAccount),
+			)
+
+			r.Post("/", twofactor.NewSaveTOTPHandler(log, mfaService))
+			r.Post("/confirm", twofactor.NewConfirmTOTPHandler(log, mfaService))
+			r.Delete("/", twofactor.NewDeleteTOTPHandler(log, mfaService))
+		},
+	)
+
+	router.Route(
+		"/admin/users",
+		func(r chi.Router) {
+			r.Use(
+				authenticate,
+				appmiddleware.RequireScope(log, auth.ScopeAdmin),
+				appmiddleware.RequireRole(log, auth.RoleAdmin),
+			)
+
+			r.Get("/", admin.NewGetUsersHandler(log, storage))
+			r.Post("/{id}/suspend", admin.NewSuspendUserHandler(log, storage))
+			r.Post("/{id}/reactivate", admin.NewReactivateUserHandler(log, storage))
+			r.Put("/{id}/role", admin.NewUpdateUserRoleHandler(log, storage))
+			r.Delete("/{id}", admin.NewDeleteUserHandler(log, storage))
+		},
+	)
+
+	router.Route(
+		"/admin/lockouts",
+		func(r chi.Router) {
+			r.Use(
+				authenticate,
+				appmiddleware.RequireScope(log, auth.ScopeAdmin),
+				appmiddleware.RequireRole(log, auth.RoleAdmin),
+			)
 
-			r.Get("/", notes.NewGetNoteHandler(log, storage))
-			r.Put("/", notes.NewUpdateNoteHandler(log, storage))
-			r.Delete("/", notes.NewDeleteNoteHandler(log, storage))
+			r.Get("/", admin.NewGetLockoutsHandler(log, guard))
+			r.Delete("/{kind}/{subject}", admin.NewDeleteLockoutHandler(log, guard))
 		},
 	)
 
@@ -0,0 +1,189 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/mailer"
+)
+
+var (
+	ErrNoEmail              = errors.New("user has no email")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+)
+
+type Store interface {
+	GetUser(ctx context.Context, userID int) (models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	SaveEmailVerificationToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	SavePasswordResetToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(ctx context.Context, tokenHash string) (int64, error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int64, error)
+}
+
+type TokenGenerator interface {
+	GenerateOpaqueToken() (string, string, error)
+	HashToken(token string) string
+}
+
+type PasswordHasher interface {
+	HashPassword(password string) (string, error)
+}
+
+type UserTokensRevoker interface {
+	RevokeUserTokens(ctx context.Context, userID int) error
+}
+
+// Service implements the flows that prove control over the user's email:
+// email verification and password reset. Both send a single-use link with a token
+// whose hash is stored until it's used or expires.
+type Service struct {
+	store             Store
+	tokenGenerator    TokenGenerator
+	passwordHasher    PasswordHasher
+	userTokensRevoker UserTokensRevoker
+	mailer            mailer.Mailer
+	appURL            string
+	verificationTTL   time.Duration
+	passwordResetTTL  time.Duration
+}
+
+func NewService(
+	store Store,
+	tokenGenerator TokenGenerator,
+	passwordHasher PasswordHasher,
+	userTokensRevoker UserTokensRevoker,
+	mailer mailer.Mailer,
+	appURL string,
+	verificationTTL, passwordResetTTL time.Duration,
+) *Service {
+	return &Service{
+		store:             store,
+		tokenGenerator:    tokenGenerator,
+		passwordHasher:    passwordHasher,
+		userTokensRevoker: userTokensRevoker,
+		mailer:            mailer,
+		appURL:            appURL,
+		verificationTTL:   verificationTTL,
+		passwordResetTTL:  passwordResetTTL,
+	}
+}
+
+func (s *Service) SendVerificationEmail(ctx context.Context, userID int) error {
+	const op = "account.SendVerificationEmail"
+
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Email == nil {
+		return fmt.Errorf("%s: %w", op, ErrNoEmail)
+	}
+	if user.EmailVerifiedAt != nil {
+		return fmt.Errorf("%s: %w", op, ErrEmailAlreadyVerified)
+	}
+
+	token, tokenHash, err := s.tokenGenerator.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.store.SaveEmailVerificationToken(ctx, userID, tokenHash, time.Now().Add(s.verificationTTL)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      *user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nfollow the link to confirm your email:\n%s\n\nThe link expires in %s.\n",
+			user.Username,
+			s.link("/verify-email", token),
+			s.verificationTTL,
+		),
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) VerifyEmail(ctx context.Context, token string) (int64, error) {
+	const op = "account.VerifyEmail"
+
+	userID, err := s.store.VerifyEmail(ctx, s.tokenGenerator.HashToken(token))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// RequestPasswordReset sends a reset link if a user with the email exists.
+// It doesn't report unknown emails, so the endpoint can't be used to look up accounts.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "account.RequestPasswordReset"
+
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	token, tokenHash, err := s.tokenGenerator.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.store.SavePasswordResetToken(ctx, int(user.ID), tokenHash, time.Now().Add(s.passwordResetTTL)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      *user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nfollow the link to set a new password:\n%s\n\nThe link expires in %s. "+
+				"If you didn't ask to reset your password, ignore this email.\n",
+			user.Username,
+			s.link("/reset-password", token),
+			s.passwordResetTTL,
+		),
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password and logs the user out everywhere.
+func (s *Service) ResetPassword(ctx context.Context, token, password string) (int64, error) {
+	const op = "account.ResetPassword"
+
+	passwordHash, err := s.passwordHasher.HashPassword(password)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userID, err := s.store.ResetPassword(ctx, s.tokenGenerator.HashToken(token), passwordHash)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.userTokensRevoker.RevokeUserTokens(ctx, int(userID)); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+func (s *Service) link(path, token string) string {
+	return s.appURL + path + "?" + url.Values{"token": {token}}.Encode()
+}
@@ -0,0 +1,32 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"todo/pkg/auth"
+)
+
+// resetStore fails the test if the password is stored, other methods aren't used.
+type resetStore struct {
+	Store
+	t *testing.T
+}
+
+func (s resetStore) ResetPassword(context.Context, string, string) (int64, error) {
+	s.t.Fatal("ResetPassword() reached the store")
+
+	return 0, nil
+}
+
+func TestResetPasswordTooLong(t *testing.T) {
+	manager := &auth.Manager{}
+	s := NewService(resetStore{t: t}, manager, manager, nil, nil, "", 0, 0)
+
+	// 40 runes pass the validator's max=72 but take 80 bytes
+	_, err := s.ResetPassword(context.Background(), "token", strings.Repeat("ü", 40))
+	if !errors.Is(err, auth.ErrPasswordTooLong) {
+		t.Errorf("ResetPassword() error = %v, want %v", err, auth.ErrPasswordTooLong)
+	}
+}
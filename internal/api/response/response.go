@@ -3,6 +3,7 @@ package response
 import (
 	"fmt"
 	"github.com/go-playground/validator/v10"
+	"reflect"
 	"strings"
 	"todo/internal/models"
 )
@@ -32,10 +33,28 @@ func ValidationErrorsResponse(errs validator.ValidationErrors) models.Response {
 		switch err.ActualTag() {
 		case "required":
 			msgErrs = append(msgErrs, fmt.Sprintf("%s is a required field", err.Field()))
+		case "min":
+			msgErrs = append(msgErrs, fmt.Sprintf("%s must be at least %s%s", err.Field(), err.Param(), unit(err.Kind())))
+		case "max":
+			msgErrs = append(msgErrs, fmt.Sprintf("%s must be at most %s%s", err.Field(), err.Param(), unit(err.Kind())))
+		case "oneof":
+			msgErrs = append(msgErrs, fmt.Sprintf("%s must be one of: %s", err.Field(), err.Param()))
 		default:
-			msgErrs = append(msgErrs, fmt.Sprintf("field %s isn't valid"), err.Field())
+			msgErrs = append(msgErrs, fmt.Sprintf("field %s isn't valid", err.Field()))
 		}
 	}
 
 	return Err(strings.Join(msgErrs, ", "))
 }
+
+// unit tells what min and max count for a field of kind.
+func unit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items long"
+	default:
+		return ""
+	}
+}
@@ -12,9 +12,23 @@ type Config struct {
 	Env                  string        `yaml:"env" env-required:"true"`
 	ConnectionString     string        `yaml:"connection_string" env-required:"true"`
 	AccessTokenTTl       time.Duration `yaml:"access_token_ttl" env-required:"true"`
+	RefreshTokenTTL      time.Duration `yaml:"refresh_token_ttl" env-required:"true"`
+	RevocationCacheTTL   time.Duration `yaml:"revocation_cache_ttl" env-default:"5s"`
+	MFATokenTTL          time.Duration `yaml:"mfa_token_ttl" env-default:"5m"`
+	TOTPIssuer           string        `yaml:"totp_issuer" env-default:"todo"`
+	AppURL               string        `yaml:"app_url" env-required:"true"`
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl" env-default:"24h"`
+	PasswordResetTTL     time.Duration `yaml:"password_reset_ttl" env-default:"1h"`
 	StandardQueryTimeout time.Duration `yaml:"standard_query_timeout" env-required:"true"`
+	MaxNoteRevisions     int           `yaml:"max_note_revisions" env-default:"50"`
 	RequestTimeout       time.Duration `yaml:"request_timeout" env-required:"true"`
 	HTTPServer           `yaml:"http-server"`
+	JWT                  JWT       `yaml:"jwt"`
+	Mailer               Mailer    `yaml:"mailer"`
+	OIDC                 OIDC      `yaml:"oidc"`
+	Lockout              Lockout   `yaml:"lockout"`
+	Trash                Trash     `yaml:"trash"`
+	Reminders            Reminders `yaml:"reminders"`
 }
 
 type HTTPServer struct {
@@ -23,6 +37,68 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-required:"true"`
 }
 
+type JWT struct {
+	Keys []JWTKey `yaml:"keys"`
+}
+
+type JWTKey struct {
+	ID             string    `yaml:"id"`
+	Algorithm      string    `yaml:"algorithm"`
+	PrivateKeyPath string    `yaml:"private_key_path"`
+	PublicKeyPath  string    `yaml:"public_key_path"`
+	SignFrom       time.Time `yaml:"sign_from"`
+	RetireAt       time.Time `yaml:"retire_at"`
+}
+
+type Mailer struct {
+	Driver string `yaml:"driver" env-default:"file"`
+	From   string `yaml:"from" env-required:"true"`
+	Dir    string `yaml:"dir"`
+	SMTP   SMTP   `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+}
+
+type Lockout struct {
+	MaxAttempts   int           `yaml:"max_attempts" env-default:"5"`
+	IPMaxAttempts int           `yaml:"ip_max_attempts" env-default:"50"`
+	BaseDelay     time.Duration `yaml:"base_delay" env-default:"1m"`
+	MaxDelay      time.Duration `yaml:"max_delay" env-default:"1h"`
+	CacheTTL      time.Duration `yaml:"cache_ttl" env-default:"5s"`
+}
+
+// Trash is how long deleted notes are kept before they are purged for good.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Reminders is how often due reminders are looked for and how failed deliveries
+// are retried: after BaseDelay, doubled with every failure up to MaxDelay.
+// WebhookHTTPSOnly refuses webhook urls with plain http.
+type Reminders struct {
+	PollInterval     time.Duration `yaml:"poll_interval" env-default:"30s"`
+	DeliveryTimeout  time.Duration `yaml:"delivery_timeout" env-default:"10s"`
+	MaxAttempts      int           `yaml:"max_attempts" env-default:"5"`
+	BaseDelay        time.Duration `yaml:"base_delay" env-default:"1m"`
+	MaxDelay         time.Duration `yaml:"max_delay" env-default:"1h"`
+	WebhookHTTPSOnly bool          `yaml:"webhook_https_only" env-default:"true"`
+}
+
+// OIDC login is enabled when Issuer is set.
+type OIDC struct {
+	Issuer       string        `yaml:"issuer"`
+	ClientID     string        `yaml:"client_id"`
+	ClientSecret string        `yaml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string        `yaml:"redirect_url"`
+	StateTTL     time.Duration `yaml:"state_ttl" env-default:"10m"`
+}
+
 func MustLoad() *Config {
 	cfg := Config{}
 
@@ -39,5 +115,13 @@ func MustLoad() *Config {
 		log.Fatalf("unable to read file: %v", err)
 	}
 
+	// time.NewTicker panics on a non-positive interval
+	if cfg.Trash.PurgeInterval <= 0 {
+		log.Fatalf("trash.purge_interval must be positive, got %s", cfg.Trash.PurgeInterval)
+	}
+	if cfg.Reminders.PollInterval <= 0 {
+		log.Fatalf("reminders.poll_interval must be positive, got %s", cfg.Reminders.PollInterval)
+	}
+
 	return &cfg
 }
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	resp "todo/internal/api/response"
+	"todo/internal/lockout"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type Unlocker interface {
+	Unlock(ctx context.Context, k lockout.Key) (models.AuthLockout, error)
+}
+
+// NewDeleteLockoutHandler lifts the lockout of /{kind}/{subject}, for example /username/alice.
+func NewDeleteLockoutHandler(log *slog.Logger, unlocker Unlocker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.NewDeleteLockoutHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		k := lockout.Key{Kind: chi.URLParam(r, "kind"), Subject: chi.URLParam(r, "subject")}
+		switch k.Kind {
+		case lockout.KindUsername:
+			k = lockout.Username(k.Subject)
+		case lockout.KindUser, lockout.KindIP:
+		default:
+			log.Info("unknown lockout kind", slog.String("kind", k.Kind))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err(`kind must be one of "username", "user" or "ip"`))
+
+			return
+		}
+
+		_, err := unlocker.Unlock(r.Context(), k)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to unlock", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoAuthLockout) {
+			log.Info("failed to unlock", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no failed attempts for this subject"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to unlock", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("unlocked by admin", slog.String("kind", k.Kind), slog.String("subject", k.Subject))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/pkg/logger/sl"
+)
+
+type LockoutsGetter interface {
+	Lockouts(ctx context.Context) ([]models.AuthLockout, error)
+}
+
+func NewGetLockoutsHandler(log *slog.Logger, lockoutsGetter LockoutsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.NewGetLockoutsHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		lockouts, err := lockoutsGetter.Lockouts(r.Context())
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to get lockouts", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get lockouts", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("got lockouts", slog.Int("count", len(lockouts)))
+
+		render.JSON(w, r, models.GetAuthLockoutsResponse{
+			Response: resp.OK(),
+			Lockouts: lockouts,
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/pkg/logger/sl"
+)
+
+type UsersGetter interface {
+	GetUsers(ctx context.Context, limit, offset int) ([]models.User, error)
+}
+
+func NewGetUsersHandler(log *slog.Logger, usersGetter UsersGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.NewGetUsersHandler"
+		resLimit := 50
+		resOffset := 0
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			var err error
+
+			resLimit, err = strconv.Atoi(limit)
+			if err != nil || resLimit < 1 {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("limit must be a positive number"))
+
+				return
+			}
+		}
+
+		if offset := r.URL.Query().Get("offset"); offset != "" {
+			var err error
+
+			resOffset, err = strconv.Atoi(offset)
+			if err != nil || resOffset < 0 {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("offset must be a non-negative number"))
+
+				return
+			}
+		}
+
+		users, err := usersGetter.GetUsers(r.Context(), resLimit, resOffset)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to get users", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get users", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("got users", slog.Int("count", len(users)))
+
+		render.JSON(w, r, models.GetUsersResponse{
+			Response: resp.OK(),
+			Users:    users,
+		})
+	}
+}
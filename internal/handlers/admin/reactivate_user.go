@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type UserReactivator interface {
+	ReactivateUser(ctx context.Context, userID int) error
+}
+
+func NewReactivateUserHandler(log *slog.Logger, userReactivator UserReactivator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.NewReactivateUserHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		err = userReactivator.ReactivateUser(r.Context(), userID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to reactivate user", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("failed to reactivate user", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no user with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to reactivate user", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("user reactivated", slog.Int("id", userID))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	appmiddleware "todo/internal/middleware"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type UserSuspender interface {
+	SuspendUser(ctx context.Context, userID int) error
+}
+
+// NewSuspendUserHandler suspends the user. Suspended users are rejected by
+// the Authenticate middleware until they are reactivated.
+func NewSuspendUserHandler(log *slog.Logger, userSuspender UserSuspender) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.NewSuspendUserHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		claims, ok := appmiddleware.ClaimsFromContext(r.Context())
+		if !ok {
+			log.Error("no token claims in context, Authenticate must run before the handler")
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		if claims.UserID == userID {
+			log.Info("admin tried to suspend themselves", slog.Int("sub", claims.UserID))
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("you can't suspend your own account"))
+
+			return
+		}
+
+		err = userSuspender.SuspendUser(r.Context(), userID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to suspend user", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("failed to suspend user", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no user with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to suspend user", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("user suspended", slog.Int("id", userID))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,129 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	appmiddleware "todo/internal/middleware"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type UserRoleUpdater interface {
+	UpdateUserRole(ctx context.Context, userID int, role string) error
+}
+
+func NewUpdateUserRoleHandler(log *slog.Logger, userRoleUpdater UserRoleUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.NewUpdateUserRoleHandler"
+		var req models.UpdateUserRoleRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.String("role", req.Role))
+
+		err = validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		claims, ok := appmiddleware.ClaimsFromContext(r.Context())
+		if !ok {
+			log.Error("no token claims in context, Authenticate must run before the handler")
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		// an admin can't demote themselves, so there is always at least one admin left
+		if claims.UserID == userID {
+			log.Info("admin tried to change their own role", slog.Int("sub", claims.UserID))
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("you can't change your own role"))
+
+			return
+		}
+
+		err = userRoleUpdater.UpdateUserRole(r.Context(), userID, req.Role)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to update user role", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("failed to update user role", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no user with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to update user role", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("user role updated", slog.Int("id", userID), slog.String("role", req.Role))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,158 @@
+package calendar
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"todo/internal/models"
+	"todo/pkg/ical"
+)
+
+const (
+	ComponentEvent = "VEVENT"
+	ComponentTodo  = "VTODO"
+)
+
+// icalPriorities maps the note priority to the iCalendar one, where 1 is the highest and 9 the lowest.
+var icalPriorities = [...]int{0, 9, 5, 1}
+
+// feed returns the calendar with a component of the given kind for each note.
+func feed(notes []models.Note, kind string) ical.Component {
+	cal := ical.Component{Name: "VCALENDAR"}
+	cal.Add("VERSION", "2.0")
+	cal.Add("PRODID", "-//todo//calendar feed//EN")
+	cal.Add("CALSCALE", "GREGORIAN")
+	cal.Add("METHOD", "PUBLISH")
+	cal.Add("X-WR-CALNAME", "todo")
+
+	for _, note := range notes {
+		cal.Components = append(cal.Components, noteComponent(note, kind))
+	}
+
+	return cal
+}
+
+func noteComponent(note models.Note, kind string) ical.Component {
+	c := ical.Component{Name: kind}
+	c.Add("UID", fmt.Sprintf("note-%d@todo", note.ID))
+	c.Add("DTSTAMP", ical.DateTime(note.UpdatedAt))
+	c.Add("CREATED", ical.DateTime(note.CreatedAt))
+	c.Add("LAST-MODIFIED", ical.DateTime(note.UpdatedAt))
+
+	if kind == ComponentTodo {
+		c.Add("DUE", ical.DateTime(*note.DueAt))
+	} else {
+		c.Add("DTSTART", ical.DateTime(*note.DueAt))
+	}
+
+	c.Add("SUMMARY", ical.Escape(note.Title))
+	if note.Content != "" {
+		c.Add("DESCRIPTION", ical.Escape(note.Content))
+	}
+
+	if len(note.Tags) > 0 {
+		tags := make([]string, 0, len(note.Tags))
+		for _, tag := range note.Tags {
+			tags = append(tags, ical.Escape(tag))
+		}
+
+		c.Add("CATEGORIES", strings.Join(tags, ","))
+	}
+
+	if note.Priority > 0 {
+		c.Add("PRIORITY", strconv.Itoa(icalPriorities[note.Priority]))
+	}
+
+	if kind == ComponentTodo {
+		switch note.Status {
+		case models.NoteStatusDone:
+			c.Add("STATUS", "COMPLETED")
+		case models.NoteStatusInProgress:
+			c.Add("STATUS", "IN-PROCESS")
+		default:
+			c.Add("STATUS", "NEEDS-ACTION")
+		}
+
+		if note.CompletedAt != nil {
+			c.Add("COMPLETED", ical.DateTime(*note.CompletedAt))
+		}
+	}
+
+	return c
+}
+
+// importedNote maps a VTODO or VEVENT to a note. A VTODO is due at DUE, or at
+// DTSTART without it, a VEVENT at DTSTART. An RRULE is kept with the TZID of that time.
+func importedNote(c ical.Component) (models.Request, error) {
+	var note models.Request
+
+	if p, ok := c.Get("SUMMARY"); ok {
+		note.Title = strings.TrimSpace(p.Text())
+	}
+
+	if p, ok := c.Get("DESCRIPTION"); ok {
+		note.Content = p.Text()
+	}
+
+	due, ok := c.Get("DUE")
+	if !ok || c.Name == ComponentEvent {
+		due, ok = c.Get("DTSTART")
+	}
+	if ok {
+		t, err := due.Time()
+		if err != nil {
+			return models.Request{}, err
+		}
+
+		note.DueAt = &t
+	}
+
+	if p, ok := c.Get("STATUS"); ok {
+		switch strings.ToUpper(p.Value) {
+		case "COMPLETED":
+			note.Status = models.NoteStatusDone
+		case "IN-PROCESS":
+			note.Status = models.NoteStatusInProgress
+		}
+	}
+
+	if p, ok := c.Get("PRIORITY"); ok {
+		n, err := strconv.Atoi(p.Value)
+		if err != nil {
+			return models.Request{}, fmt.Errorf("%w: PRIORITY %q is not a number", ical.ErrInvalidCalendar, p.Value)
+		}
+
+		note.Priority = notePriority(n)
+	}
+
+	for _, p := range c.All("CATEGORIES") {
+		for _, tag := range p.Texts() {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !slices.Contains(note.Tags, tag) {
+				note.Tags = append(note.Tags, tag)
+			}
+		}
+	}
+
+	if p, ok := c.Get("RRULE"); ok {
+		note.RRule = p.Value
+		note.Timezone = due.Params["TZID"]
+	}
+
+	return note, nil
+}
+
+// notePriority maps an iCalendar priority to the note one: 1 to 4 is high, 5 medium and 6 to 9 low.
+func notePriority(n int) int {
+	switch {
+	case n >= 1 && n <= 4:
+		return 3
+	case n == 5:
+		return 2
+	case n >= 6 && n <= 9:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,163 @@
+package calendar
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"todo/internal/models"
+	"todo/pkg/ical"
+)
+
+func TestNotePriority(t *testing.T) {
+	for n, want := range map[int]int{0: 0, 1: 3, 4: 3, 5: 2, 6: 1, 9: 1, 10: 0, -1: 0} {
+		if got := notePriority(n); got != want {
+			t.Errorf("notePriority(%d) = %d, want %d", n, got, want)
+		}
+	}
+
+	// the priorities survive an export and an import
+	for priority := 0; priority <= 3; priority++ {
+		if got := notePriority(icalPriorities[priority]); got != priority {
+			t.Errorf("notePriority(icalPriorities[%d]) = %d", priority, got)
+		}
+	}
+}
+
+func TestNoteRoundTrip(t *testing.T) {
+	dueAt := time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC)
+	note := models.Note{
+		ID:       7,
+		Title:    "Pay rent; before noon, please",
+		Content:  "Bank\ntransfer",
+		Status:   models.NoteStatusInProgress,
+		Priority: 3,
+		DueAt:    &dueAt,
+		Tags:     []string{"home", "a,b"},
+	}
+
+	for _, kind := range []string{ComponentTodo, ComponentEvent} {
+		t.Run(kind, func(t *testing.T) {
+			c := noteComponent(note, kind)
+
+			if uid, _ := c.Get("UID"); uid.Value != "note-7@todo" {
+				t.Errorf("UID = %q, want note-7@todo", uid.Value)
+			}
+
+			got, err := importedNote(c)
+			if err != nil {
+				t.Fatalf("importedNote(): %v", err)
+			}
+
+			want := models.Request{
+				Title:    note.Title,
+				Content:  note.Content,
+				Priority: note.Priority,
+				DueAt:    note.DueAt,
+				Tags:     note.Tags,
+			}
+			// an event has no status
+			if kind == ComponentTodo {
+				want.Status = note.Status
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("importedNote() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestImportedNote(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       ical.Component
+		want    models.Request
+		wantErr bool
+	}{
+		{
+			name: "todo due at DUE",
+			c: ical.Component{Name: ComponentTodo, Properties: []ical.Property{
+				{Name: "SUMMARY", Value: "  Call mom  "},
+				{Name: "DTSTART", Value: "20261019T090000Z"},
+				{Name: "DUE", Value: "20261020T090000Z"},
+				{Name: "STATUS", Value: "completed"},
+			}},
+			want: models.Request{
+				Title:  "Call mom",
+				Status: models.NoteStatusDone,
+				DueAt:  ptr(time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name: "todo due at DTSTART without DUE",
+			c: ical.Component{Name: ComponentTodo, Properties: []ical.Property{
+				{Name: "SUMMARY", Value: "Call mom"},
+				{Name: "DTSTART", Params: map[string]string{"VALUE": "DATE"}, Value: "20261019"},
+			}},
+			want: models.Request{
+				Title: "Call mom",
+				DueAt: ptr(time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name: "recurring event with a tzid",
+			c: ical.Component{Name: ComponentEvent, Properties: []ical.Property{
+				{Name: "SUMMARY", Value: "Standup"},
+				{Name: "DTSTART", Params: map[string]string{"TZID": "UTC"}, Value: "20261019T090000"},
+				{Name: "RRULE", Value: "FREQ=WEEKLY;BYDAY=MO,WE"},
+				{Name: "CATEGORIES", Value: "work"},
+				{Name: "CATEGORIES", Value: "work,team"},
+			}},
+			want: models.Request{
+				Title:    "Standup",
+				DueAt:    ptr(time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)),
+				Tags:     []string{"work", "team"},
+				RRule:    "FREQ=WEEKLY;BYDAY=MO,WE",
+				Timezone: "UTC",
+			},
+		},
+		{
+			name: "no due date",
+			c: ical.Component{Name: ComponentTodo, Properties: []ical.Property{
+				{Name: "SUMMARY", Value: "Someday"},
+			}},
+			want: models.Request{Title: "Someday"},
+		},
+		{
+			name: "invalid priority",
+			c: ical.Component{Name: ComponentTodo, Properties: []ical.Property{
+				{Name: "PRIORITY", Value: "high"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid due date",
+			c: ical.Component{Name: ComponentTodo, Properties: []ical.Property{
+				{Name: "DUE", Value: "soon"},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importedNote(tt.c)
+			if tt.wantErr {
+				if !errors.Is(err, ical.ErrInvalidCalendar) {
+					t.Errorf("importedNote() = %+v, %v, want ErrInvalidCalendar", got, err)
+				}
+
+				return
+			}
+
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("importedNote() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
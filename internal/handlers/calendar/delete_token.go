@@ -0,0 +1,76 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type TokenDeleter interface {
+	DeleteCalendarToken(ctx context.Context, userID int) error
+}
+
+// NewDeleteTokenHandler revokes the calendar feed token, subscribed calendars stop updating.
+func NewDeleteTokenHandler(log *slog.Logger, tokenDeleter TokenDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.calendar.NewDeleteTokenHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		err = tokenDeleter.DeleteCalendarToken(r.Context(), userID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to delete calendar token", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoCalendarToken) {
+			log.Info("failed to delete calendar token", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no calendar token"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete calendar token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("calendar token deleted")
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,122 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/ical"
+	"todo/pkg/logger/sl"
+)
+
+type FeedGetter interface {
+	UseCalendarToken(ctx context.Context, userID int, tokenHash string) error
+	GetCalendarNotes(ctx context.Context, userID int) ([]models.Note, error)
+}
+
+type TokenHasher interface {
+	HashToken(token string) string
+}
+
+// NewGetFeedHandler serves the user's notes with a due_at as an iCalendar feed.
+// Calendar apps can't send a bearer token, so the feed is authenticated by the
+// ?token= of NewSaveTokenHandler. The notes are events, or tasks with ?component=vtodo.
+func NewGetFeedHandler(log *slog.Logger, feedGetter FeedGetter, tokenHasher TokenHasher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.calendar.NewGetFeedHandler"
+		var buf bytes.Buffer
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		kind := ComponentEvent
+		if component := r.URL.Query().Get("component"); component != "" {
+			kind = strings.ToUpper(component)
+			if kind != ComponentEvent && kind != ComponentTodo {
+				log.Info("unknown component", slog.String("component", component))
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err(`component must be either "vevent" or "vtodo"`))
+
+				return
+			}
+		}
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			log.Info("calendar token is missing")
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("invalid calendar token"))
+
+			return
+		}
+
+		err = feedGetter.UseCalendarToken(r.Context(), userID, tokenHasher.HashToken(token))
+		if err == nil {
+			var notes []models.Note
+
+			notes, err = feedGetter.GetCalendarNotes(r.Context(), userID)
+			if err == nil {
+				err = ical.Encode(&buf, feed(notes, kind))
+				log = log.With(slog.Int("count", len(notes)))
+			}
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to get calendar feed", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoCalendarToken) {
+			log.Info("failed to get calendar feed", sl.Err(err))
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("invalid calendar token"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get calendar feed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("got calendar feed")
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+		w.Write(buf.Bytes())
+	}
+}
@@ -0,0 +1,153 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/pkg/ical"
+	"todo/pkg/logger/sl"
+	"todo/pkg/rrule"
+)
+
+const (
+	maxImportBytes = 1 << 20
+	// maxImportNotes is the operation limit of a batch request, the notes are created
+	// in one transaction, so that a failed import can be retried without duplicates
+	maxImportNotes = 100
+)
+
+type NotesBatcher interface {
+	BatchNotes(ctx context.Context, userID int, ops []models.NoteOperation, bestEffort bool) ([]models.NoteOperationResult, error)
+}
+
+// NewImportCalendarHandler creates a note for each VTODO and VEVENT of an .ics file
+// sent as the request body. Components that don't make a valid note, or whose note
+// can't be created, are skipped and counted. The response lists the created notes.
+func NewImportCalendarHandler(log *slog.Logger, notesBatcher NotesBatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.calendar.NewImportCalendarHandler"
+		var maxBytesErr *http.MaxBytesError
+		var ops []models.NoteOperation
+		var skipped int
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		cal, err := ical.Parse(http.MaxBytesReader(w, r.Body, maxImportBytes))
+		if errors.As(err, &maxBytesErr) {
+			log.Info("calendar is too large", sl.Err(err))
+
+			w.WriteHeader(413)
+			render.JSON(w, r, resp.Err("calendar must be at most 1 MiB"))
+
+			return
+		}
+		if err != nil {
+			log.Info("calendar parsing failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid calendar"))
+
+			return
+		}
+
+		validate := validator.New()
+
+		for _, c := range cal.Components {
+			if c.Name != ComponentTodo && c.Name != ComponentEvent {
+				continue
+			}
+
+			note, err := importedNote(c)
+			if err == nil {
+				err = validate.Struct(note)
+			}
+			if err == nil && note.RRule != "" {
+				_, err = rrule.Parse(note.RRule)
+			}
+			if err != nil {
+				log.Info("calendar component skipped", sl.Err(err))
+				skipped++
+
+				continue
+			}
+
+			ops = append(ops, models.NoteOperation{Op: models.NoteOpCreate, Note: &note})
+		}
+
+		if len(ops) > maxImportNotes {
+			log.Info("calendar has too many components", slog.Int("count", len(ops)))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("calendar must have at most "+strconv.Itoa(maxImportNotes)+" tasks and events"))
+
+			return
+		}
+
+		ids := make([]int64, 0, len(ops))
+
+		if len(ops) > 0 {
+			results, err := notesBatcher.BatchNotes(r.Context(), userID, ops, true)
+			for _, result := range results {
+				if result.Err != nil {
+					log.Warn("calendar component skipped", sl.Err(result.Err))
+					skipped++
+
+					continue
+				}
+
+				ids = append(ids, result.ID)
+			}
+			if errors.Is(err, context.Canceled) {
+				log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Warn("failed to import calendar", sl.Err(err))
+
+				w.WriteHeader(504)
+				render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to import calendar", sl.Err(err))
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+		}
+
+		log.Info("calendar imported", slog.Int("count", len(ids)), slog.Int("skipped", skipped))
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.ImportCalendarResponse{
+			Response: resp.OK(),
+			IDs:      ids,
+			Skipped:  skipped,
+		})
+	}
+}
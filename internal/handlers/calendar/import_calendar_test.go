@@ -0,0 +1,82 @@
+package calendar
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"todo/internal/models"
+)
+
+// notesBatcherFunc creates the notes of every call in memory.
+type notesBatcherFunc func(ops []models.NoteOperation) []models.NoteOperationResult
+
+func (f notesBatcherFunc) BatchNotes(_ context.Context, _ int, ops []models.NoteOperation, _ bool) ([]models.NoteOperationResult, error) {
+	return f(ops), nil
+}
+
+func calendarWithTodos(n int) string {
+	var b strings.Builder
+
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n")
+	for i := range n {
+		b.WriteString("BEGIN:VTODO\r\nUID:" + strconv.Itoa(i) + "@test\r\nSUMMARY:task " + strconv.Itoa(i) + "\r\nEND:VTODO\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+
+	return b.String()
+}
+
+func TestImportCalendarLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		todos      int
+		wantStatus int
+		wantBatch  int
+	}{
+		{name: "at the limit", todos: maxImportNotes, wantStatus: 201, wantBatch: maxImportNotes},
+		{name: "over the limit", todos: maxImportNotes + 1, wantStatus: 400},
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var batches []int
+			batcher := notesBatcherFunc(func(ops []models.NoteOperation) []models.NoteOperationResult {
+				batches = append(batches, len(ops))
+
+				results := make([]models.NoteOperationResult, len(ops))
+				for i := range results {
+					results[i].ID = int64(i + 1)
+				}
+
+				return results
+			})
+
+			r := httptest.NewRequest("POST", "/users/1/calendar/import", strings.NewReader(calendarWithTodos(tt.todos)))
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", "1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+			w := httptest.NewRecorder()
+
+			NewImportCalendarHandler(log, batcher)(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			// all notes of an import are created in one transaction
+			if tt.wantBatch == 0 && len(batches) != 0 {
+				t.Errorf("BatchNotes() calls = %v, want none", batches)
+			}
+			if tt.wantBatch != 0 && (len(batches) != 1 || batches[0] != tt.wantBatch) {
+				t.Errorf("BatchNotes() calls = %v, want one of %d", batches, tt.wantBatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/pkg/logger/sl"
+)
+
+type TokenSaver interface {
+	SaveCalendarToken(ctx context.Context, userID int, tokenHash string) error
+}
+
+type TokenGenerator interface {
+	GenerateOpaqueToken() (string, string, error)
+}
+
+// NewSaveTokenHandler creates the calendar feed token of the user, replacing the previous one,
+// and returns it with the url calendar apps subscribe to. The token is only shown here.
+func NewSaveTokenHandler(log *slog.Logger, tokenSaver TokenSaver, tokenGenerator TokenGenerator, appURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.calendar.NewSaveTokenHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		token, tokenHash, err := tokenGenerator.GenerateOpaqueToken()
+		if err != nil {
+			log.Error("failed to generate calendar token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		err = tokenSaver.SaveCalendarToken(r.Context(), userID, tokenHash)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to save calendar token", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to save calendar token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("calendar token saved")
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.SaveCalendarTokenResponse{
+			Response: resp.OK(),
+			Token:    token,
+			URL:      strings.TrimSuffix(appURL, "/") + "/users/" + strconv.Itoa(userID) + "/calendar.ics?token=" + url.QueryEscape(token),
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package items
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type ItemDeleter interface {
+	DeleteNoteItem(ctx context.Context, itemID, noteID, userID int) (int64, error)
+}
+
+func NewDeleteItemHandler(log *slog.Logger, itemDeleter ItemDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.items.NewDeleteItemHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("note id must be a number"))
+
+			return
+		}
+
+		itemID, err := strconv.Atoi(chi.URLParam(r, "item_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("item id must be a number"))
+
+			return
+		}
+
+		id, err := itemDeleter.DeleteNoteItem(r.Context(), itemID, noteID, userID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to delete item", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNotes) {
+			log.Info("failed to delete item", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no note with this id"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNoteItem) {
+			log.Info("failed to delete item", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no item with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete item", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("item deleted", slog.Int64("id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,127 @@
+package items
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type ItemsReorderer interface {
+	ReorderNoteItems(ctx context.Context, noteID, userID int, itemIDs []int64) error
+}
+
+// NewReorderItemsHandler moves the items of a note into the order of item_ids.
+func NewReorderItemsHandler(log *slog.Logger, itemsReorderer ItemsReorderer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.items.NewReorderItemsHandler"
+		var req models.ReorderNoteItemsRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("note id must be a number"))
+
+			return
+		}
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.Any("request", req))
+
+		err = validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		err = itemsReorderer.ReorderNoteItems(r.Context(), noteID, userID, req.ItemIDs)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to reorder items", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNotes) {
+			log.Info("failed to reorder items", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no note with this id"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidItemsOrder) {
+			log.Info("failed to reorder items", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("item_ids must list every item of the note exactly once"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to reorder items", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("items reordered", slog.Int("count", len(req.ItemIDs)))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
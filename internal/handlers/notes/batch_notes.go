@@ -0,0 +1,218 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type NotesBatcher interface {
+	BatchNotes(ctx context.Context, userID int, ops []models.NoteOperation, bestEffort bool) ([]models.NoteOperationResult, error)
+}
+
+// NewBatchNotesHandler runs a list of create, update, delete and complete operations
+// in one transaction and answers with a status for each of them. Without best_effort
+// nothing is applied unless every operation succeeds, and the response gets
+// the status of the operation that failed.
+func NewBatchNotesHandler(log *slog.Logger, notesBatcher NotesBatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.notes.NewBatchNotesHandler"
+		var req models.NoteBatchRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.Int("operations", len(req.Operations)), slog.Bool("best_effort", req.BestEffort))
+
+		validate := validator.New()
+
+		err = validate.Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		results := make([]models.NoteBatchResult, len(req.Operations))
+		ops := make([]models.NoteOperation, 0, len(req.Operations))
+		// indexes[i] is the index in req.Operations of ops[i]
+		indexes := make([]int, 0, len(req.Operations))
+
+		for i, noteOp := range req.Operations {
+			if msg := validateOperation(validate, noteOp); msg != "" {
+				results[i] = models.NoteBatchResult{Status: 400, Error: msg}
+
+				continue
+			}
+
+			ops = append(ops, noteOp)
+			indexes = append(indexes, i)
+		}
+
+		if len(ops) < len(req.Operations) && !req.BestEffort {
+			log.Info("batch has invalid operations")
+
+			for _, i := range indexes {
+				results[i] = models.NoteBatchResult{Status: 424, Error: "not run because another operation is invalid"}
+			}
+
+			w.WriteHeader(400)
+			render.JSON(w, r, models.NoteBatchResponse{
+				Response: resp.Err("batch has invalid operations"),
+				Results:  results,
+			})
+
+			return
+		}
+
+		if len(ops) > 0 {
+			opResults, err := notesBatcher.BatchNotes(r.Context(), userID, ops, req.BestEffort)
+			if errors.Is(err, context.Canceled) {
+				log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Warn("failed to run batch", sl.Err(err))
+
+				w.WriteHeader(504)
+				render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to run batch", sl.Err(err))
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+
+			for j, opResult := range opResults {
+				results[indexes[j]] = batchResult(log, ops[j].Op, opResult)
+			}
+		}
+
+		if !req.BestEffort {
+			for _, result := range results {
+				if result.Status >= 400 && result.Status != 424 {
+					log.Info("batch rolled back", slog.Int("status", result.Status))
+
+					w.WriteHeader(result.Status)
+					render.JSON(w, r, models.NoteBatchResponse{
+						Response: resp.Err("batch was rolled back"),
+						Results:  results,
+					})
+
+					return
+				}
+			}
+		}
+
+		log.Info("batch done", slog.Int("operations", len(ops)))
+
+		render.JSON(w, r, models.NoteBatchResponse{
+			Response: resp.OK(),
+			Results:  results,
+		})
+	}
+}
+
+// validateOperation returns why the operation is invalid, or an empty string.
+// Notes are validated like in NewSaveNoteHandler and NewUpdateNoteHandler.
+func validateOperation(validate *validator.Validate, noteOp models.NoteOperation) string {
+	var validationErrs validator.ValidationErrors
+
+	switch noteOp.Op {
+	case models.NoteOpCreate:
+	case models.NoteOpUpdate, models.NoteOpDelete, models.NoteOpComplete:
+		if noteOp.NoteID < 1 {
+			return "note_id is a required field"
+		}
+	default:
+		return "op must be one of: create update delete complete"
+	}
+
+	if noteOp.Op != models.NoteOpCreate && noteOp.Op != models.NoteOpUpdate {
+		return ""
+	}
+
+	if noteOp.Note == nil {
+		return "note is a required field"
+	}
+
+	err := validate.Struct(noteOp.Note)
+	if errors.As(err, &validationErrs) {
+		return resp.ValidationErrorsResponse(validationErrs).Error
+	}
+	if err != nil {
+		return "invalid note"
+	}
+
+	return checkRRule(*noteOp.Note)
+}
+
+func batchResult(log *slog.Logger, noteOp string, opResult models.NoteOperationResult) models.NoteBatchResult {
+	switch {
+	case opResult.Err == nil && noteOp == models.NoteOpCreate:
+		return models.NoteBatchResult{Status: 201, ID: opResult.ID, Version: opResult.Version}
+	case opResult.Err == nil:
+		return models.NoteBatchResult{Status: 200, ID: opResult.ID, Version: opResult.Version}
+	case errors.Is(opResult.Err, storage.ErrBatchAborted):
+		return models.NoteBatchResult{Status: 424, Error: "rolled back because another operation failed"}
+	case errors.Is(opResult.Err, storage.ErrNoNotes):
+		return models.NoteBatchResult{Status: 404, Error: "no note with this id"}
+	case errors.Is(opResult.Err, storage.ErrNoProject):
+		return models.NoteBatchResult{Status: 400, Error: "no project with this id"}
+	case errors.Is(opResult.Err, storage.ErrNoteVersionMismatch):
+		return models.NoteBatchResult{Status: 412, Error: "note was changed since it was read"}
+	default:
+		log.Error("batch operation failed", slog.String("operation", noteOp), sl.Err(opResult.Err))
+
+		return models.NoteBatchResult{Status: 500, Error: "internal error"}
+	}
+}
@@ -10,14 +10,18 @@ import (
 	"net/http"
 	"strconv"
 	resp "todo/internal/api/response"
+	"todo/internal/models"
 	"todo/internal/storage"
 	"todo/pkg/logger/sl"
 )
 
 type NoteDeleter interface {
-	DeleteNote(ctx context.Context, noteID, userID int) (int64, error)
+	GetNote(ctx context.Context, noteID, userID int) (models.Note, int64, error)
+	DeleteNote(ctx context.Context, noteID, userID int, version int64) (int64, error)
 }
 
+// NewDeleteNoteHandler moves the note to the trash. With If-Match the note is only
+// deleted while its ETag matches, otherwise the response is 412.
 func NewDeleteNoteHandler(log *slog.Logger, noteDeleter NoteDeleter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.notes.NewDeleteNoteHandler"
@@ -47,7 +51,12 @@ func NewDeleteNoteHandler(log *slog.Logger, noteDeleter NoteDeleter) http.Handle
 			return
 		}
 
-		id, err := noteDeleter.DeleteNote(r.Context(), noteID, userID)
+		var id int64
+
+		version, err := ifMatchVersion(r.Context(), noteDeleter, noteID, userID, r.Header.Get("If-Match"))
+		if err == nil {
+			id, err = noteDeleter.DeleteNote(r.Context(), noteID, userID, version)
+		}
 		if errors.Is(err, context.Canceled) {
 			log.Info("connection closed from client side, request cancelled", sl.Err(err))
 
@@ -69,6 +78,14 @@ func NewDeleteNoteHandler(log *slog.Logger, noteDeleter NoteDeleter) http.Handle
 
 			return
 		}
+		if errors.Is(err, storage.ErrNoteVersionMismatch) {
+			log.Info("failed to delete note", sl.Err(err))
+
+			w.WriteHeader(412)
+			render.JSON(w, r, resp.Err("note was changed since it was read"))
+
+			return
+		}
 		if err != nil {
 			log.Error("failed to delete note", sl.Err(err))
 
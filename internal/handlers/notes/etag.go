@@ -0,0 +1,82 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"todo/internal/storage"
+)
+
+// etag is the entity tag of a note version.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion returns the note version a change guarded by an If-Match header
+// applies to: 0 without the header, otherwise the current version of the note when
+// the header matches it, which the change is then made against. It returns
+// storage.ErrNoteVersionMismatch when the header doesn't match.
+func ifMatchVersion(ctx context.Context, noteGetter NoteGetter, noteID, userID int, header string) (int64, error) {
+	if header == "" {
+		return 0, nil
+	}
+
+	note, _, err := noteGetter.GetNote(ctx, noteID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !matches(header, note.Version) {
+		return 0, fmt.Errorf("if-match %s: %w", header, storage.ErrNoteVersionMismatch)
+	}
+
+	return note.Version, nil
+}
+
+// matches reports whether an If-Match header matches the version, when any of its
+// tags does. Weak tags never match, as If-Match uses the strong comparison.
+func matches(header string, version int64) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+
+		if v, ok := parseETag(tag); ok && v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// noneMatch reports whether an If-None-Match header matches the version,
+// using the weak comparison.
+func noneMatch(header string, version int64) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+
+		if v, ok := parseETag(strings.TrimPrefix(tag, "W/")); ok && v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseETag(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,113 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int64
+		want    bool
+	}{
+		{header: `"2"`, version: 2, want: true},
+		{header: `"1"`, version: 2, want: false},
+		{header: `"1", "2"`, version: 2, want: true},
+		{header: `"1","2"`, version: 2, want: true},
+		{header: `"2", "1"`, version: 2, want: true},
+		{header: `*`, version: 7, want: true},
+		{header: `W/"2"`, version: 2, want: false},
+		{header: `W/"2", "2"`, version: 2, want: true},
+		{header: `2`, version: 2, want: false},
+		{header: `"abc", "2"`, version: 2, want: true},
+		{header: `"0"`, version: 0, want: false},
+		{header: `"-1"`, version: 2, want: false},
+		{header: ``, version: 2, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := matches(tt.header, tt.version); got != tt.want {
+				t.Errorf("matches(%q, %d) = %v, want %v", tt.header, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int64
+		want    bool
+	}{
+		{header: `"3"`, version: 3, want: true},
+		{header: `W/"3"`, version: 3, want: true},
+		{header: `"1", W/"3"`, version: 3, want: true},
+		{header: `"1", "2"`, version: 3, want: false},
+		{header: `*`, version: 3, want: true},
+		{header: ``, version: 3, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := noneMatch(tt.header, tt.version); got != tt.want {
+				t.Errorf("noneMatch(%q, %d) = %v, want %v", tt.header, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	if got := etag(42); got != `"42"` {
+		t.Errorf(`etag(42) = %s, want "42"`, got)
+	}
+
+	if v, ok := parseETag(etag(42)); !ok || v != 42 {
+		t.Errorf("parseETag(etag(42)) = %d, %v, want 42, true", v, ok)
+	}
+}
+
+type noteGetterFunc func(ctx context.Context, noteID, userID int) (models.Note, int64, error)
+
+func (f noteGetterFunc) GetNote(ctx context.Context, noteID, userID int) (models.Note, int64, error) {
+	return f(ctx, noteID, userID)
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	getter := noteGetterFunc(func(context.Context, int, int) (models.Note, int64, error) {
+		return models.Note{ID: 1, Version: 2}, 0, nil
+	})
+
+	tests := []struct {
+		name    string
+		header  string
+		want    int64
+		wantErr error
+	}{
+		{name: "no header", header: "", want: 0},
+		{name: "current version", header: `"2"`, want: 2},
+		{name: "any of the tags", header: `"1", "2"`, want: 2},
+		{name: "any version", header: `*`, want: 2},
+		{name: "old version", header: `"1"`, wantErr: storage.ErrNoteVersionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ifMatchVersion(context.Background(), getter, 1, 1, tt.header)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("ifMatchVersion(%q) = %d, %v, want %d, %v", tt.header, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	missing := noteGetterFunc(func(context.Context, int, int) (models.Note, int64, error) {
+		return models.Note{}, 0, storage.ErrNoNotes
+	})
+	if _, err := ifMatchVersion(context.Background(), missing, 1, 1, `"1"`); !errors.Is(err, storage.ErrNoNotes) {
+		t.Errorf("ifMatchVersion() of a missing note = %v, want ErrNoNotes", err)
+	}
+}
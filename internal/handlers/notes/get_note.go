@@ -19,6 +19,8 @@ type NoteGetter interface {
 	GetNote(ctx context.Context, noteID, userID int) (models.Note, int64, error)
 }
 
+// NewGetNoteHandler returns the note with its version as the ETag, 304 when
+// If-None-Match has it.
 func NewGetNoteHandler(log *slog.Logger, noteGetter NoteGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.notes.NewGetNoteHandler"
@@ -79,6 +81,16 @@ func NewGetNoteHandler(log *slog.Logger, noteGetter NoteGetter) http.HandlerFunc
 			return
 		}
 
+		w.Header().Set("ETag", etag(note.Version))
+
+		if noneMatch(r.Header.Get("If-None-Match"), note.Version) {
+			log.Info("note not modified", slog.Int64("id", id))
+
+			w.WriteHeader(304)
+
+			return
+		}
+
 		log.Info("got note", slog.Int64("id", id))
 
 		render.JSON(w, r, models.GetNoteResponse{
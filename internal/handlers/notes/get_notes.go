@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 	resp "todo/internal/api/response"
 	"todo/internal/models"
 	"todo/internal/storage"
@@ -17,15 +18,18 @@ import (
 )
 
 type NotesGetter interface {
-	GetNotes(ctx context.Context, userID, limit, offset int, sort string) ([]models.Note, []int64, error)
+	GetNotes(ctx context.Context, userID, limit int, cursor string, sort []models.SortKey, filter models.NoteFilter) ([]models.Note, string, error)
 }
 
+const maxNotesLimit = 100
+
+// NewGetNotesHandler returns a page of notes. The next page is requested
+// with ?cursor= set to next_cursor of the previous one.
 func NewGetNotesHandler(log *slog.Logger, notesGetter NotesGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.notes.NewGetNotesHandler"
-		resSort := "ASC"
 		resLimit := 10
-		resOffset := 0
+		var filter models.NoteFilter
 
 		log := log.With(
 			slog.String("op", op),
@@ -44,47 +48,154 @@ func NewGetNotesHandler(log *slog.Logger, notesGetter NotesGetter) http.HandlerF
 
 		if limit := r.URL.Query().Get("limit"); limit != "" {
 			resLimit, err = strconv.Atoi(limit)
+			if err != nil || resLimit < 1 || resLimit > maxNotesLimit {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("limit must be a number from 1 to 100"))
+
+				return
+			}
+		}
+
+		sort, err := parseSort(r.URL.Query().Get("sort"))
+		if err != nil {
+			log.Info("invalid sort", slog.String("sort", r.URL.Query().Get("sort")))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err(err.Error()))
+
+			return
+		}
+
+		if status := r.URL.Query().Get("status"); status != "" {
+			for _, s := range strings.Split(status, ",") {
+				switch s {
+				case models.NoteStatusOpen, models.NoteStatusInProgress, models.NoteStatusDone:
+					filter.Statuses = append(filter.Statuses, s)
+				default:
+					log.Info("unknown status", slog.String("status", s))
+
+					w.WriteHeader(400)
+					render.JSON(w, r, resp.Err(`status must be a comma separated list of "open", "in_progress" and "done"`))
+
+					return
+				}
+			}
+		}
+
+		if priority := r.URL.Query().Get("priority"); priority != "" {
+			p, err := strconv.Atoi(priority)
+			if err != nil || p < 0 || p > 3 {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("priority must be a number from 0 to 3"))
+
+				return
+			}
+
+			filter.Priority = &p
+		}
+
+		if dueBefore := r.URL.Query().Get("due_before"); dueBefore != "" {
+			t, err := time.Parse(time.RFC3339, dueBefore)
+			if err != nil {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("due_before must be an RFC 3339 time"))
+
+				return
+			}
+
+			filter.DueBefore = &t
+		}
+
+		if dueAfter := r.URL.Query().Get("due_after"); dueAfter != "" {
+			t, err := time.Parse(time.RFC3339, dueAfter)
 			if err != nil {
 				log.Info("query parameter conversion error")
 
 				w.WriteHeader(400)
-				render.JSON(w, r, resp.Err("limit must be a number"))
+				render.JSON(w, r, resp.Err("due_after must be an RFC 3339 time"))
 
 				return
 			}
+
+			filter.DueAfter = &t
 		}
 
-		if offset := r.URL.Query().Get("offset"); offset != "" {
-			resOffset, err = strconv.Atoi(offset)
+		if createdAfter := r.URL.Query().Get("created_after"); createdAfter != "" {
+			t, err := time.Parse(time.RFC3339, createdAfter)
 			if err != nil {
 				log.Info("query parameter conversion error")
 
 				w.WriteHeader(400)
-				render.JSON(w, r, resp.Err("offset must be a number"))
+				render.JSON(w, r, resp.Err("created_after must be an RFC 3339 time"))
 
 				return
 			}
+
+			filter.CreatedAfter = &t
 		}
 
-		if sort := r.URL.Query().Get("sort"); sort != "" {
-			resSort = sort
+		if updatedSince := r.URL.Query().Get("updated_since"); updatedSince != "" {
+			t, err := time.Parse(time.RFC3339, updatedSince)
+			if err != nil {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("updated_since must be an RFC 3339 time"))
+
+				return
+			}
+
+			filter.UpdatedSince = &t
 		}
 
-		switch strings.ToLower(resSort) {
-		case "asc":
-			resSort = "ASC"
-		case "desc":
-			resSort = "DESC"
-		default:
-			log.Info("value of sort is not safe", slog.String("sort", resSort))
+		filter.TitleContains = r.URL.Query().Get("title_contains")
 
-			w.WriteHeader(400)
-			render.JSON(w, r, resp.Err(`sort must be either "asc" or "desc"`))
+		// /users/{id}/projects/{project_id}/notes lists the notes of one project
+		project := chi.URLParam(r, "project_id")
+		if project == "" {
+			project = r.URL.Query().Get("project")
+		}
 
-			return
+		if project == models.ProjectNotesInbox {
+			filter.Inbox = true
+		} else if project != "" {
+			projectID, err := strconv.ParseInt(project, 10, 64)
+			if err != nil {
+				log.Info("query parameter conversion error", sl.Err(err))
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err(`project must be a project id or "inbox"`))
+
+				return
+			}
+
+			filter.ProjectID = &projectID
+			filter.Subprojects = r.URL.Query().Get("subprojects") == "true"
 		}
 
-		notes, ids, err := notesGetter.GetNotes(r.Context(), userID, resLimit, resOffset, resSort)
+		filter.Tags = r.URL.Query()["tag"]
+		filter.TagMode = models.TagModeAny
+
+		if tagMode := r.URL.Query().Get("tag_mode"); tagMode != "" {
+			if tagMode != models.TagModeAny && tagMode != models.TagModeAll {
+				log.Info("unknown tag mode", slog.String("tag_mode", tagMode))
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err(`tag_mode must be either "any" or "all"`))
+
+				return
+			}
+
+			filter.TagMode = tagMode
+		}
+
+		notes, nextCursor, err := notesGetter.GetNotes(r.Context(), userID, resLimit, r.URL.Query().Get("cursor"), sort, filter)
 		if errors.Is(err, context.Canceled) {
 			log.Info("connection closed from client side, request cancelled", sl.Err(err))
 
@@ -98,11 +209,19 @@ func NewGetNotesHandler(log *slog.Logger, notesGetter NotesGetter) http.HandlerF
 
 			return
 		}
-		if errors.Is(err, storage.ErrUserNoNotes) {
+		if errors.Is(err, storage.ErrInvalidSort) {
 			log.Info("failed to get notes", sl.Err(err))
 
-			w.WriteHeader(404)
-			render.JSON(w, r, resp.Err("user have no notes or offset is too big"))
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err(errInvalidSort.Error()))
+
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			log.Info("failed to get notes", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("cursor is invalid or was issued for another sort order"))
 
 			return
 		}
@@ -115,11 +234,12 @@ func NewGetNotesHandler(log *slog.Logger, notesGetter NotesGetter) http.HandlerF
 			return
 		}
 
-		log.Info("got notes", slog.Any("ids", ids))
+		log.Info("got notes", slog.Int("count", len(notes)), slog.Bool("has_next", nextCursor != ""))
 
 		render.JSON(w, r, models.GetNotesResponse{
-			Response: resp.OK(),
-			Notes:    notes,
+			Response:   resp.OK(),
+			Notes:      notes,
+			NextCursor: nextCursor,
 		})
 	}
 }
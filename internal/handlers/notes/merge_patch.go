@@ -0,0 +1,113 @@
+package notes
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"todo/internal/models"
+)
+
+// mergePatch applies an RFC 7396 merge patch to a decoded JSON document:
+// objects are merged recursively, null removes a member, anything else replaces it.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any, len(patchObject))
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+
+			continue
+		}
+
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+// patchNote applies a merge patch to the writable fields of the note.
+// Members the note doesn't have are an error.
+func patchNote(note models.Note, patch map[string]any) (models.Request, error) {
+	var req models.Request
+	var target any
+
+	current, err := json.Marshal(noteRequest(note))
+	if err != nil {
+		return models.Request{}, err
+	}
+
+	if err := json.Unmarshal(current, &target); err != nil {
+		return models.Request{}, err
+	}
+
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return models.Request{}, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&req); err != nil {
+		return models.Request{}, err
+	}
+
+	return req, nil
+}
+
+func noteRequest(note models.Note) models.Request {
+	return models.Request{
+		Title:     note.Title,
+		Content:   note.Content,
+		Status:    note.Status,
+		Priority:  note.Priority,
+		DueAt:     note.DueAt,
+		Tags:      note.Tags,
+		ProjectID: note.ProjectID,
+		RRule:     note.RRule,
+		Timezone:  note.Timezone,
+	}
+}
+
+// changedFields returns the models.NoteField* names of the fields req changes in the note.
+func changedFields(note models.Note, req models.Request) []string {
+	var fields []string
+
+	if req.Title != note.Title {
+		fields = append(fields, models.NoteFieldTitle)
+	}
+	if req.Content != note.Content {
+		fields = append(fields, models.NoteFieldContent)
+	}
+	if req.Status != note.Status && !(req.Status == "" && note.Status == models.NoteStatusOpen) {
+		fields = append(fields, models.NoteFieldStatus)
+	}
+	if req.Priority != note.Priority {
+		fields = append(fields, models.NoteFieldPriority)
+	}
+	if (req.DueAt == nil) != (note.DueAt == nil) || (req.DueAt != nil && !req.DueAt.Equal(*note.DueAt)) {
+		fields = append(fields, models.NoteFieldDueAt)
+	}
+	if !slices.Equal(req.Tags, note.Tags) {
+		fields = append(fields, models.NoteFieldTags)
+	}
+	if (req.ProjectID == nil) != (note.ProjectID == nil) || (req.ProjectID != nil && *req.ProjectID != *note.ProjectID) {
+		fields = append(fields, models.NoteFieldProject)
+	}
+	if req.RRule != note.RRule {
+		fields = append(fields, models.NoteFieldRRule)
+	}
+	if req.Timezone != note.Timezone {
+		fields = append(fields, models.NoteFieldTimezone)
+	}
+
+	return fields
+}
@@ -0,0 +1,142 @@
+package notes
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+	"todo/internal/models"
+)
+
+// TestMergePatch checks the examples of RFC 7396, appendix A.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{target: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{target: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{target: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{target: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{target: `["a","b"]`, patch: `["c","d"]`, want: `["c","d"]`},
+		{target: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{target: `{"a":"foo"}`, patch: `null`, want: `null`},
+		{target: `{"a":"foo"}`, patch: `"bar"`, want: `"bar"`},
+		{target: `{"e":null}`, patch: `{"a":1}`, want: `{"a":1,"e":null}`},
+		{target: `[1,2]`, patch: `{"a":"b","c":null}`, want: `{"a":"b"}`},
+		{target: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			var target, patch any
+
+			if err := json.Unmarshal([]byte(tt.target), &target); err != nil {
+				t.Fatalf("target: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("patch: %v", err)
+			}
+
+			got, err := json.Marshal(mergePatch(target, patch))
+			if err != nil {
+				t.Fatalf("json.Marshal(): %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("mergePatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func testNote() models.Note {
+	dueAt := time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC)
+	projectID := int64(4)
+
+	return models.Note{
+		ID:        1,
+		Title:     "Pay rent",
+		Content:   "before the 20th",
+		Status:    models.NoteStatusOpen,
+		Priority:  2,
+		DueAt:     &dueAt,
+		Tags:      []string{"home", "money"},
+		ProjectID: &projectID,
+		Version:   3,
+	}
+}
+
+func TestPatchNote(t *testing.T) {
+	tests := []struct {
+		name       string
+		patch      string
+		wantFields []string
+		wantErr    bool
+	}{
+		{name: "nothing", patch: `{}`},
+		{name: "same title", patch: `{"title":"Pay rent"}`},
+		{name: "title", patch: `{"title":"Pay the rent"}`, wantFields: []string{models.NoteFieldTitle}},
+		{
+			name:       "remove due_at and project",
+			patch:      `{"due_at":null,"project_id":null}`,
+			wantFields: []string{models.NoteFieldDueAt, models.NoteFieldProject},
+		},
+		{name: "tags are replaced", patch: `{"tags":["money"]}`, wantFields: []string{models.NoteFieldTags}},
+		{name: "same due_at in another zone", patch: `{"due_at":"2026-10-20T11:00:00+02:00"}`},
+		{
+			name:       "status and priority",
+			patch:      `{"status":"done","priority":3}`,
+			wantFields: []string{models.NoteFieldStatus, models.NoteFieldPriority},
+		},
+		{
+			name:       "recurrence",
+			patch:      `{"rrule":"FREQ=MONTHLY","timezone":"Europe/Berlin"}`,
+			wantFields: []string{models.NoteFieldRRule, models.NoteFieldTimezone},
+		},
+		{name: "unknown member", patch: `{"owner":2}`, wantErr: true},
+		{name: "read only member", patch: `{"version":9}`, wantErr: true},
+		{name: "wrong type", patch: `{"priority":"high"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch map[string]any
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("patch: %v", err)
+			}
+
+			note := testNote()
+
+			req, err := patchNote(note, patch)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("patchNote() = %+v, want an error", req)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("patchNote(): %v", err)
+			}
+
+			if got := changedFields(note, req); !slices.Equal(got, tt.wantFields) {
+				t.Errorf("changedFields() = %v, want %v", got, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestChangedFieldsOpenStatus(t *testing.T) {
+	req := noteRequest(testNote())
+	req.Status = ""
+
+	if got := changedFields(testNote(), req); len(got) != 0 {
+		t.Errorf("changedFields() of an omitted open status = %v, want none", got)
+	}
+}
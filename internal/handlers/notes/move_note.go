@@ -0,0 +1,111 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type NoteMover interface {
+	MoveNote(ctx context.Context, noteID, userID int, projectID *int64) (models.Note, error)
+}
+
+// NewMoveNoteHandler puts the note in project_id, or in the inbox when it is null.
+func NewMoveNoteHandler(log *slog.Logger, noteMover NoteMover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.notes.NewMoveNoteHandler"
+		var req models.MoveNoteRequest
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("note id must be a number"))
+
+			return
+		}
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.Any("request", req))
+
+		note, err := noteMover.MoveNote(r.Context(), noteID, userID, req.ProjectID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to move note", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNotes) {
+			log.Info("failed to move note", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no note with this id"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoProject) {
+			log.Info("failed to move note", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("no project with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to move note", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("note moved", slog.Int64("id", note.ID))
+
+		w.Header().Set("ETag", etag(note.Version))
+		render.JSON(w, r, models.GetNoteResponse{
+			Response: resp.OK(),
+			Note:     note,
+		})
+	}
+}
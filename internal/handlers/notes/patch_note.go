@@ -0,0 +1,234 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type NotePatcher interface {
+	GetNote(ctx context.Context, noteID, userID int) (models.Note, int64, error)
+	PatchNote(ctx context.Context, noteID, userID int, note models.Request, fields []string, version int64) (models.Note, error)
+}
+
+// NewPatchNoteHandler applies an RFC 7396 merge patch to the note and writes only
+// the fields it changes. If-Match is honoured like in NewUpdateNoteHandler.
+func NewPatchNoteHandler(log *slog.Logger, notePatcher NotePatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.notes.NewPatchNoteHandler"
+		var patch map[string]any
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("note id must be a number"))
+
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || (mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+			log.Info("unsupported content type", slog.String("content_type", r.Header.Get("Content-Type")))
+
+			w.WriteHeader(415)
+			render.JSON(w, r, resp.Err("content type must be application/merge-patch+json"))
+
+			return
+		}
+
+		// a patch that isn't an object would replace the whole note, which is never valid
+		if err := render.DecodeJSON(r.Body, &patch); err != nil || patch == nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.Any("patch", patch))
+
+		note, _, err := notePatcher.GetNote(r.Context(), noteID, userID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to get note", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNotes) {
+			log.Info("failed to get note", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no note with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get note", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		if header := r.Header.Get("If-Match"); header != "" && !matches(header, note.Version) {
+			log.Info("note version mismatch", slog.Int64("version", note.Version))
+
+			w.WriteHeader(412)
+			render.JSON(w, r, resp.Err("note was changed since it was read"))
+
+			return
+		}
+
+		req, err := patchNote(note, patch)
+		if err != nil {
+			log.Info("patch application failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		err = validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		if msg := checkRRule(req); msg != "" {
+			log.Info("request validation failed", slog.String("rrule", req.RRule))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err(msg))
+
+			return
+		}
+
+		fields := changedFields(note, req)
+		if len(fields) == 0 {
+			log.Info("patch changes nothing", slog.Int64("id", note.ID))
+
+			w.Header().Set("ETag", etag(note.Version))
+			render.JSON(w, r, models.GetNoteResponse{
+				Response: resp.OK(),
+				Note:     note,
+			})
+
+			return
+		}
+
+		// the patch was applied to this version, a change in between must not be overwritten
+		note, err = notePatcher.PatchNote(r.Context(), noteID, userID, req, fields, note.Version)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to patch note", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNotes) {
+			log.Info("failed to patch note", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no note with this id"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoProject) {
+			log.Info("failed to patch note", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("no project with this id"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoteVersionMismatch) && r.Header.Get("If-Match") != "" {
+			log.Info("failed to patch note", sl.Err(err))
+
+			w.WriteHeader(412)
+			render.JSON(w, r, resp.Err("note was changed since it was read"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoteVersionMismatch) {
+			log.Info("failed to patch note", sl.Err(err))
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("note was changed by another request, try again"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to patch note", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("note patched", slog.Int64("id", note.ID), slog.Any("fields", fields))
+
+		w.Header().Set("ETag", etag(note.Version))
+		render.JSON(w, r, models.GetNoteResponse{
+			Response: resp.OK(),
+			Note:     note,
+		})
+	}
+}
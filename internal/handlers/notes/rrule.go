@@ -0,0 +1,19 @@
+package notes
+
+import (
+	"todo/internal/models"
+	"todo/pkg/rrule"
+)
+
+// checkRRule returns why the recurrence rule of the note can't be used, or an empty string.
+func checkRRule(note models.Request) string {
+	if note.RRule == "" {
+		return ""
+	}
+
+	if _, err := rrule.Parse(note.RRule); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
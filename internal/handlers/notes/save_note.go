@@ -12,11 +12,12 @@ import (
 	"strconv"
 	resp "todo/internal/api/response"
 	"todo/internal/models"
+	"todo/internal/storage"
 	"todo/pkg/logger/sl"
 )
 
 type NoteSaver interface {
-	SaveNote(ctx context.Context, userID int, title, content string) (int64, error)
+	SaveNote(ctx context.Context, userID int, note models.Request) (int64, error)
 }
 
 func NewSaveNoteHandler(log *slog.Logger, noteSaver NoteSaver) http.HandlerFunc {
@@ -69,7 +70,16 @@ func NewSaveNoteHandler(log *slog.Logger, noteSaver NoteSaver) http.HandlerFunc
 			return
 		}
 
-		id, err := noteSaver.SaveNote(r.Context(), userID, req.Title, req.Content)
+		if msg := checkRRule(req); msg != "" {
+			log.Info("request validation failed", slog.String("rrule", req.RRule))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err(msg))
+
+			return
+		}
+
+		id, err := noteSaver.SaveNote(r.Context(), userID, req)
 		if errors.Is(err, context.Canceled) {
 			log.Info("connection closed from client side, request cancelled", sl.Err(err))
 
@@ -83,6 +93,14 @@ func NewSaveNoteHandler(log *slog.Logger, noteSaver NoteSaver) http.HandlerFunc
 
 			return
 		}
+		if errors.Is(err, storage.ErrNoProject) {
+			log.Info("failed to save note", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("no project with this id"))
+
+			return
+		}
 		if err != nil {
 			log.Error("failed to save note", sl.Err(err))
 
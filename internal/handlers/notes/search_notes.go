@@ -0,0 +1,118 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type NotesSearcher interface {
+	SearchNotes(ctx context.Context, userID int, query string, limit, offset int) ([]models.NoteSearchResult, error)
+}
+
+// NewSearchNotesHandler searches the user's notes for ?q=. Words are ANDed,
+// "quoted words" are a phrase, -word excludes, word* matches a prefix and OR
+// between two words matches either.
+func NewSearchNotesHandler(log *slog.Logger, notesSearcher NotesSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.notes.NewSearchNotesHandler"
+		resLimit := 10
+		resOffset := 0
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			log.Info("search query is missing")
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("q is a required query parameter"))
+
+			return
+		}
+
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			resLimit, err = strconv.Atoi(limit)
+			if err != nil {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("limit must be a number"))
+
+				return
+			}
+		}
+
+		if offset := r.URL.Query().Get("offset"); offset != "" {
+			resOffset, err = strconv.Atoi(offset)
+			if err != nil {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("offset must be a number"))
+
+				return
+			}
+		}
+
+		results, err := notesSearcher.SearchNotes(r.Context(), userID, query, resLimit, resOffset)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to search notes", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrEmptySearchQuery) {
+			log.Info("failed to search notes", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("q has no words to search for"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to search notes", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("notes searched", slog.Int("count", len(results)))
+
+		render.JSON(w, r, models.SearchNotesResponse{
+			Response: resp.OK(),
+			Results:  results,
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package notes
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"todo/internal/models"
+)
+
+var errInvalidSort = errors.New(`sort must be a comma separated list of fields, each optionally prefixed with "-" for descending order; fields are ` + strings.Join(models.NoteSortFields, ", "))
+
+// parseSort parses sort=-updated_at,title. The former "asc" and "desc"
+// values sort by created_at.
+func parseSort(sort string) ([]models.SortKey, error) {
+	switch strings.ToLower(sort) {
+	case "", "asc":
+		return []models.SortKey{{Field: "created_at"}}, nil
+	case "desc":
+		return []models.SortKey{{Field: "created_at", Desc: true}}, nil
+	}
+
+	fields := strings.Split(sort, ",")
+	keys := make([]models.SortKey, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		if !slices.Contains(models.NoteSortFields, field) || seen[field] {
+			return nil, errInvalidSort
+		}
+		seen[field] = true
+
+		keys = append(keys, models.SortKey{Field: field, Desc: desc})
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,46 @@
+package notes
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"todo/internal/models"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		sort    string
+		want    []models.SortKey
+		wantErr bool
+	}{
+		{sort: "", want: []models.SortKey{{Field: "created_at"}}},
+		{sort: "asc", want: []models.SortKey{{Field: "created_at"}}},
+		{sort: "DESC", want: []models.SortKey{{Field: "created_at", Desc: true}}},
+		{sort: "title", want: []models.SortKey{{Field: "title"}}},
+		{sort: "-updated_at,title", want: []models.SortKey{{Field: "updated_at", Desc: true}, {Field: "title"}}},
+		{sort: " -priority , due_at ", want: []models.SortKey{{Field: "priority", Desc: true}, {Field: "due_at"}}},
+		{sort: "title,-title", wantErr: true},
+		{sort: "password", wantErr: true},
+		{sort: "title;DROP TABLE notes", wantErr: true},
+		{sort: "--title", wantErr: true},
+		{sort: "title,", wantErr: true},
+		{sort: "+title", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			got, err := parseSort(tt.sort)
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidSort) {
+					t.Errorf("parseSort(%q) = %v, %v, want errInvalidSort", tt.sort, got, err)
+				}
+
+				return
+			}
+
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("parseSort(%q) = %v, %v, want %v", tt.sort, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -17,9 +17,12 @@ import (
 )
 
 type NoteUpdater interface {
-	UpdateNote(ctx context.Context, noteID, userID int, title, content string) (int64, error)
+	GetNote(ctx context.Context, noteID, userID int) (models.Note, int64, error)
+	UpdateNote(ctx context.Context, noteID, userID int, note models.Request, version int64) (int64, error)
 }
 
+// NewUpdateNoteHandler replaces the note. With If-Match the note is only replaced
+// while its ETag matches, otherwise the response is 412.
 func NewUpdateNoteHandler(log *slog.Logger, noteUpdater NoteUpdater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.notes.NewUpdateNoteHandler"
@@ -80,7 +83,19 @@ func NewUpdateNoteHandler(log *slog.Logger, noteUpdater NoteUpdater) http.Handle
 			return
 		}
 
-		id, err := noteUpdater.UpdateNote(r.Context(), noteID, userID, req.Title, req.Content)
+		if msg := checkRRule(req); msg != "" {
+			log.Info("request validation failed", slog.String("rrule", req.RRule))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err(msg))
+
+			return
+		}
+
+		version, err := ifMatchVersion(r.Context(), noteUpdater, noteID, userID, r.Header.Get("If-Match"))
+		if err == nil {
+			version, err = noteUpdater.UpdateNote(r.Context(), noteID, userID, req, version)
+		}
 		if errors.Is(err, context.Canceled) {
 			log.Info("connection closed from client side, request cancelled", sl.Err(err))
 
@@ -102,6 +117,22 @@ func NewUpdateNoteHandler(log *slog.Logger, noteUpdater NoteUpdater) http.Handle
 
 			return
 		}
+		if errors.Is(err, storage.ErrNoProject) {
+			log.Info("failed to update note", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("no project with this id"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoteVersionMismatch) {
+			log.Info("failed to update note", sl.Err(err))
+
+			w.WriteHeader(412)
+			render.JSON(w, r, resp.Err("note was changed since it was read"))
+
+			return
+		}
 		if err != nil {
 			log.Error("failed to update note", sl.Err(err))
 
@@ -111,8 +142,9 @@ func NewUpdateNoteHandler(log *slog.Logger, noteUpdater NoteUpdater) http.Handle
 			return
 		}
 
-		log.Info("note updated", slog.Int64("id", id))
+		log.Info("note updated", slog.Int("id", noteID), slog.Int64("version", version))
 
+		w.Header().Set("ETag", etag(version))
 		w.WriteHeader(201)
 		render.JSON(w, r, resp.OK())
 	}
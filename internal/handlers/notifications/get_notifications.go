@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/pkg/logger/sl"
+)
+
+type NotificationsGetter interface {
+	GetNotifications(ctx context.Context, userID int, unread bool, limit, offset int) ([]models.Notification, error)
+}
+
+const maxNotificationsLimit = 100
+
+// NewGetNotificationsHandler returns the in-app inbox, newest first, with ?unread=true only the unread notifications.
+func NewGetNotificationsHandler(log *slog.Logger, notificationsGetter NotificationsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.notifications.NewGetNotificationsHandler"
+		resLimit := 20
+		resOffset := 0
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			resLimit, err = strconv.Atoi(limit)
+			if err != nil || resLimit < 1 || resLimit > maxNotificationsLimit {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("limit must be a number from 1 to 100"))
+
+				return
+			}
+		}
+
+		if offset := r.URL.Query().Get("offset"); offset != "" {
+			resOffset, err = strconv.Atoi(offset)
+			if err != nil || resOffset < 0 {
+				log.Info("query parameter conversion error")
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err("offset must be a non-negative number"))
+
+				return
+			}
+		}
+
+		notifications, err := notificationsGetter.GetNotifications(r.Context(), userID, r.URL.Query().Get("unread") == "true", resLimit, resOffset)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to get notifications", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get notifications", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("got notifications", slog.Int("count", len(notifications)))
+
+		render.JSON(w, r, models.GetNotificationsResponse{
+			Response:      resp.OK(),
+			Notifications: notifications,
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type NotificationReader interface {
+	ReadNotification(ctx context.Context, notificationID, userID int) (int64, error)
+}
+
+func NewReadNotificationHandler(log *slog.Logger, notificationReader NotificationReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.notifications.NewReadNotificationHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		notificationID, err := strconv.Atoi(chi.URLParam(r, "notification_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("notification id must be a number"))
+
+			return
+		}
+
+		id, err := notificationReader.ReadNotification(r.Context(), notificationID, userID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to read notification", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNotification) {
+			log.Info("failed to read notification", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no notification with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to read notification", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("notification read", slog.Int64("id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,89 @@
+package passwords
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/pkg/logger/sl"
+)
+
+type PasswordResetRequester interface {
+	RequestPasswordReset(ctx context.Context, email string) error
+}
+
+// NewForgotPasswordHandler answers the same way whether the email is known or not.
+func NewForgotPasswordHandler(log *slog.Logger, passwordResetRequester PasswordResetRequester) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.passwords.NewForgotPasswordHandler"
+		var req models.ForgotPasswordRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.String("email", req.Email))
+
+		err := validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		err = passwordResetRequester.RequestPasswordReset(r.Context(), req.Email)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to request password reset", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to request password reset", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("password reset requested")
+
+		w.WriteHeader(202)
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,113 @@
+package passwords
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/auth"
+	"todo/pkg/logger/sl"
+)
+
+type PasswordResetter interface {
+	ResetPassword(ctx context.Context, token, password string) (int64, error)
+}
+
+func NewResetPasswordHandler(log *slog.Logger, passwordResetter PasswordResetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.passwords.NewResetPasswordHandler"
+		var req models.ResetPasswordRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded")
+
+		err := validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		id, err := passwordResetter.ResetPassword(r.Context(), req.Token, req.Password)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to reset password", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoEmailToken) {
+			log.Info("failed to reset password", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid or already used token"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrEmailTokenExpired) {
+			log.Info("failed to reset password", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("token is expired, ask for a new email"))
+
+			return
+		}
+		if errors.Is(err, auth.ErrPasswordTooLong) {
+			log.Info("failed to reset password", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("password must be at most 72 bytes"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to reset password", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("password reset", slog.Int64("id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,101 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type ProjectDeleter interface {
+	DeleteProject(ctx context.Context, projectID, userID int, notes string) (int64, error)
+}
+
+// NewDeleteProjectHandler deletes the project with its subprojects. Their notes go
+// to the inbox, or to the trash with ?notes=trash.
+func NewDeleteProjectHandler(log *slog.Logger, projectDeleter ProjectDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.projects.NewDeleteProjectHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		projectID, err := strconv.Atoi(chi.URLParam(r, "project_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("project id must be a number"))
+
+			return
+		}
+
+		notes := r.URL.Query().Get("notes")
+		if notes == "" {
+			notes = models.ProjectNotesInbox
+		}
+		if notes != models.ProjectNotesInbox && notes != models.ProjectNotesTrash {
+			log.Info("unknown notes policy", slog.String("notes", notes))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err(`notes must be either "inbox" or "trash"`))
+
+			return
+		}
+
+		id, err := projectDeleter.DeleteProject(r.Context(), projectID, userID, notes)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to delete project", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoProject) {
+			log.Info("failed to delete project", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no projects with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete project", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("project deleted", slog.Int64("id", id), slog.String("notes", notes))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,168 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/reminders"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type ReminderSaver interface {
+	SaveReminder(ctx context.Context, noteID, userID int, reminder models.ReminderRequest, offset *time.Duration) (int64, error)
+}
+
+type WebhookChecker interface {
+	CheckURL(ctx context.Context, rawURL string) error
+}
+
+// NewSaveReminderHandler adds a reminder at a fixed time, or at an offset like "-1h" from the due_at of the note.
+// Webhook urls must resolve to public addresses.
+func NewSaveReminderHandler(log *slog.Logger, reminderSaver ReminderSaver, webhookChecker WebhookChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.reminders.NewSaveReminderHandler"
+		var req models.ReminderRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("note id must be a number"))
+
+			return
+		}
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.Any("request", req))
+
+		err = validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		var offset *time.Duration
+		if req.Offset != "" {
+			d, err := time.ParseDuration(req.Offset)
+			if err != nil || d%time.Second != 0 {
+				log.Info("request validation failed", slog.String("offset", req.Offset))
+
+				w.WriteHeader(400)
+				render.JSON(w, r, resp.Err(`offset must be a duration in whole seconds like "-30m" or "1h"`))
+
+				return
+			}
+
+			offset = &d
+		}
+
+		if req.Channel == models.ReminderChannelWebhook {
+			err = webhookChecker.CheckURL(r.Context(), req.WebhookURL)
+		}
+		if errors.Is(err, reminders.ErrForbiddenWebhook) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("webhook_url must be a url of a public http(s) server"))
+
+			return
+		}
+
+		var id int64
+		if err == nil {
+			id, err = reminderSaver.SaveReminder(r.Context(), noteID, userID, req, offset)
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to save reminder", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoDueAt) {
+			log.Info("failed to save reminder", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("offset needs a note with a due_at"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNotes) {
+			log.Info("failed to save reminder", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no note with this id"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to save reminder", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("reminder saved", slog.Int64("id", id))
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.SaveReminderResponse{
+			Response: resp.OK(),
+			ID:       id,
+		})
+	}
+}
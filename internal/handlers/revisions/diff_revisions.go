@@ -0,0 +1,131 @@
+package revisions
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/diff"
+	"todo/pkg/logger/sl"
+)
+
+// NewDiffRevisionsHandler compares revisions ?from= and ?to= of a note line by line.
+func NewDiffRevisionsHandler(log *slog.Logger, revisionGetter RevisionGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.revisions.NewDiffRevisionsHandler"
+		var revisions [2]models.NoteRevision
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("note id must be a number"))
+
+			return
+		}
+
+		from, err := strconv.Atoi(r.URL.Query().Get("from"))
+		if err != nil {
+			log.Info("query parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("from must be a revision number"))
+
+			return
+		}
+
+		to, err := strconv.Atoi(r.URL.Query().Get("to"))
+		if err != nil {
+			log.Info("query parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("to must be a revision number"))
+
+			return
+		}
+
+		for i, number := range []int{from, to} {
+			revision, err := revisionGetter.GetNoteRevision(r.Context(), noteID, userID, number)
+			if errors.Is(err, context.Canceled) {
+				log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Warn("failed to get revision", sl.Err(err))
+
+				w.WriteHeader(504)
+				render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+				return
+			}
+			if errors.Is(err, storage.ErrNoNoteRevision) {
+				log.Info("failed to get revision", sl.Err(err), slog.Int("revision", number))
+
+				w.WriteHeader(404)
+				render.JSON(w, r, resp.Err("no revision with number "+strconv.Itoa(number)))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to get revision", sl.Err(err))
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+
+			revisions[i] = revision
+		}
+
+		var scripts [2][]diff.Line
+		for i, texts := range [][2]string{
+			{revisions[0].Title, revisions[1].Title},
+			{revisions[0].Content, revisions[1].Content},
+		} {
+			scripts[i], err = diff.Lines(texts[0], texts[1])
+			if errors.Is(err, diff.ErrTooLarge) {
+				log.Info("failed to compare revisions", sl.Err(err))
+
+				w.WriteHeader(422)
+				render.JSON(w, r, resp.Err("revisions are too large to compare"))
+
+				return
+			}
+		}
+
+		log.Info("revisions compared", slog.Int("from", from), slog.Int("to", to))
+
+		render.JSON(w, r, models.DiffNoteRevisionsResponse{
+			Response: resp.OK(),
+			From:     from,
+			To:       to,
+			Title:    scripts[0],
+			Content:  scripts[1],
+		})
+	}
+}
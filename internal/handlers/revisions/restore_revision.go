@@ -0,0 +1,101 @@
+package revisions
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type RevisionRestorer interface {
+	RestoreNoteRevision(ctx context.Context, noteID, userID, revision int) (models.Note, error)
+}
+
+// NewRestoreRevisionHandler puts an old revision back into the note as a new revision,
+// so the history is kept and the restore itself can be undone.
+func NewRestoreRevisionHandler(log *slog.Logger, revisionRestorer RevisionRestorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.revisions.NewRestoreRevisionHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		noteID, err := strconv.Atoi(chi.URLParam(r, "note_id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("note id must be a number"))
+
+			return
+		}
+
+		revision, err := strconv.Atoi(chi.URLParam(r, "revision"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("revision must be a number"))
+
+			return
+		}
+
+		note, err := revisionRestorer.RestoreNoteRevision(r.Context(), noteID, userID, revision)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to restore revision", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoNoteRevision) {
+			log.Info("failed to restore revision", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no revision with this number"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to restore revision", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("revision restored", slog.Int64("id", note.ID), slog.Int("revision", revision))
+
+		render.JSON(w, r, models.GetNoteResponse{
+			Response: resp.OK(),
+			Note:     note,
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	resp "todo/internal/api/response"
+	appmiddleware "todo/internal/middleware"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/auth"
+	"todo/pkg/logger/sl"
+)
+
+type TokenRevoker interface {
+	RevokeToken(ctx context.Context, claims auth.Claims) error
+}
+
+type RefreshSessionRevoker interface {
+	RevokeRefreshSession(ctx context.Context, tokenHash string, userID int) error
+}
+
+// NewDeleteSessionHandler revokes the access token of the request and,
+// if refresh_token is passed, the refresh session family it belongs to.
+func NewDeleteSessionHandler(log *slog.Logger, tokenRevoker TokenRevoker, refreshSessionRevoker RefreshSessionRevoker, tokenHasher TokenHasher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sessions.NewDeleteSessionHandler"
+		var req models.DeleteSessionRequest
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := appmiddleware.ClaimsFromContext(r.Context())
+		if !ok {
+			log.Error("no token claims in context")
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		if req.RefreshToken != "" {
+			err := refreshSessionRevoker.RevokeRefreshSession(r.Context(), tokenHasher.HashToken(req.RefreshToken), claims.UserID)
+			if errors.Is(err, context.Canceled) {
+				log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Warn("failed to revoke refresh session", sl.Err(err))
+
+				w.WriteHeader(504)
+				render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+				return
+			}
+			if errors.Is(err, storage.ErrRefreshSessionNotFound) {
+				log.Info("failed to revoke refresh session", sl.Err(err))
+
+				w.WriteHeader(404)
+				render.JSON(w, r, resp.Err("no active refresh session with this token"))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to revoke refresh session", sl.Err(err))
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+
+			log.Info("refresh session revoked")
+		}
+
+		err := tokenRevoker.RevokeToken(r.Context(), claims)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to revoke access token", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to revoke access token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("access token revoked", slog.Int("sub", claims.UserID))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,75 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	resp "todo/internal/api/response"
+	appmiddleware "todo/internal/middleware"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type UserTokensRevoker interface {
+	RevokeUserTokens(ctx context.Context, userID int) error
+}
+
+// NewDeleteSessionsHandler revokes all access tokens and refresh sessions of the user.
+func NewDeleteSessionsHandler(log *slog.Logger, userTokensRevoker UserTokensRevoker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sessions.NewDeleteSessionsHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := appmiddleware.ClaimsFromContext(r.Context())
+		if !ok {
+			log.Error("no token claims in context")
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		err := userTokensRevoker.RevokeUserTokens(r.Context(), claims.UserID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to revoke user tokens", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("failed to revoke user tokens", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no such user"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to revoke user tokens", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("user tokens revoked", slog.Int("sub", claims.UserID))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,149 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"time"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type TokenHasher interface {
+	HashToken(token string) string
+}
+
+type RefreshSessionRotator interface {
+	RotateRefreshSession(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (int64, error)
+}
+
+func NewRefreshSessionHandler(log *slog.Logger, refreshSessionRotator RefreshSessionRotator, tokenHasher TokenHasher, accessTokenGenerator AccessTokenGenerator, refreshTokenGenerator RefreshTokenGenerator, accessTokenTTL, refreshTokenTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sessions.NewRefreshSessionHandler"
+		var req models.RefreshSessionRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded")
+
+		err := validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		refreshToken, refreshTokenHash, err := refreshTokenGenerator.GenerateRefreshToken()
+		if err != nil {
+			log.Error("failed to generate refresh token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		userID, err := refreshSessionRotator.RotateRefreshSession(
+			r.Context(),
+			tokenHasher.HashToken(req.RefreshToken),
+			refreshTokenHash,
+			time.Now().Add(refreshTokenTTL),
+		)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to rotate refresh session", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrRefreshTokenReused) {
+			log.Warn("refresh token reuse detected, session family revoked", sl.Err(err))
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("invalid refresh token"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrRefreshSessionExpired) {
+			log.Info("failed to rotate refresh session", sl.Err(err))
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("refresh token is expired"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrRefreshSessionNotFound) {
+			log.Info("failed to rotate refresh session", sl.Err(err))
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("invalid refresh token"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to rotate refresh session", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("refresh session rotated", slog.Int64("user_id", userID))
+
+		accessToken, err := accessTokenGenerator.GenerateAccessToken(int(userID), accessTokenTTL)
+		if err != nil {
+			log.Error("failed to generate access token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.SaveSessionResponse{
+			Response:     resp.OK(),
+			ID:           userID,
+			AccessToken:  accessToken,
+			JWT:          accessToken,
+			RefreshToken: refreshToken,
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	resp "todo/internal/api/response"
+	"todo/internal/lockout"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/auth"
+	"todo/pkg/logger/sl"
+)
+
+type UserGetter interface {
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+}
+
+type PasswordComparer interface {
+	ComparePassword(hash, password string) error
+}
+
+type AccessTokenGenerator interface {
+	GenerateAccessToken(userID int, tokenTTL time.Duration) (string, error)
+}
+
+type RefreshTokenGenerator interface {
+	GenerateRefreshToken() (string, string, error)
+}
+
+type RefreshSessionSaver interface {
+	SaveRefreshSession(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (int64, error)
+}
+
+type MFATokenGenerator interface {
+	GenerateMFAToken(userID int, tokenTTL time.Duration) (string, error)
+}
+
+type AttemptGuard interface {
+	Check(ctx context.Context, keys ...lockout.Key) (time.Duration, error)
+	Fail(ctx context.Context, keys ...lockout.Key) error
+	Succeed(ctx context.Context, keys ...lockout.Key) error
+}
+
+func NewSaveSessionHandler(log *slog.Logger, userGetter UserGetter, passwordComparer PasswordComparer, accessTokenGenerator AccessTokenGenerator, refreshTokenGenerator RefreshTokenGenerator, refreshSessionSaver RefreshSessionSaver, mfaTokenGenerator MFATokenGenerator, attemptGuard AttemptGuard, accessTokenTTL, refreshTokenTTL, mfaTokenTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sessions.NewSaveSessionHandler"
+		var req models.SaveSessionRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.String("username", req.Username))
+
+		err := validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		keys := []lockout.Key{lockout.Username(req.Username), lockout.IP(r)}
+
+		retryAfter, err := attemptGuard.Check(r.Context(), keys...)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to check lockout", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to check lockout", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+		if retryAfter > 0 {
+			log.Info("attempt rejected by lockout", slog.Duration("retry_after", retryAfter))
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			w.WriteHeader(429)
+			render.JSON(w, r, resp.Err("too many failed attempts, try again later"))
+
+			return
+		}
+
+		user, err := userGetter.GetUserByUsername(r.Context(), req.Username)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to get user", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+			log.Error("failed to get user", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		// user.PasswordHash is empty for an unknown username, ComparePassword rejects it
+		// in the same time as a wrong password
+		err = passwordComparer.ComparePassword(user.PasswordHash, req.Password)
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			log.Info("failed to authenticate user", sl.Err(err))
+
+			if err := attemptGuard.Fail(r.Context(), keys...); err != nil {
+				log.Error("failed to record failed attempt", sl.Err(err))
+			}
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("invalid username or password"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to authenticate user", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		// only the username is reset, logging into one account must not clear
+		// the failures the address piled up against others
+		if err := attemptGuard.Succeed(r.Context(), keys[0]); err != nil {
+			log.Error("failed to reset failed attempts", sl.Err(err))
+		}
+
+		if user.SuspendedAt != nil {
+			log.Info("suspended user rejected", slog.Int64("id", user.ID))
+
+			w.WriteHeader(403)
+			render.JSON(w, r, resp.Err("account is suspended"))
+
+			return
+		}
+
+		log.Info("user authenticated", slog.Int64("id", user.ID))
+
+		if user.TOTPEnabled {
+			mfaToken, err := mfaTokenGenerator.GenerateMFAToken(int(user.ID), mfaTokenTTL)
+			if err != nil {
+				log.Error("failed to generate mfa token", sl.Err(err))
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+
+			log.Info("second factor required", slog.Int64("id", user.ID))
+
+			render.JSON(w, r, models.SaveSessionResponse{
+				Response:    resp.OK(),
+				ID:          user.ID,
+				MFARequired: true,
+				MFAToken:    mfaToken,
+			})
+
+			return
+		}
+
+		accessToken, err := accessTokenGenerator.GenerateAccessToken(int(user.ID), accessTokenTTL)
+		if err != nil {
+			log.Error("failed to generate access token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		refreshToken, refreshTokenHash, err := refreshTokenGenerator.GenerateRefreshToken()
+		if err != nil {
+			log.Error("failed to generate refresh token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		sessionID, err := refreshSessionSaver.SaveRefreshSession(r.Context(), int(user.ID), refreshTokenHash, time.Now().Add(refreshTokenTTL))
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to save refresh session", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to save refresh session", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("refresh session saved", slog.Int64("session_id", sessionID))
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.SaveSessionResponse{
+			Response:     resp.OK(),
+			ID:           user.ID,
+			AccessToken:  accessToken,
+			JWT:          accessToken,
+			RefreshToken: refreshToken,
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	resp "todo/internal/api/response"
+	"todo/internal/lockout"
+	"todo/internal/mfa"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/auth"
+	"todo/pkg/logger/sl"
+)
+
+type MFATokenParser interface {
+	ParseMFAToken(token string) (auth.Claims, error)
+}
+
+type MFAVerifier interface {
+	Verify(ctx context.Context, userID int, code string) error
+}
+
+// NewVerifySessionHandler finishes a login that requires a second factor: it takes
+// the mfa token returned by NewSaveSessionHandler and a TOTP or recovery code.
+func NewVerifySessionHandler(log *slog.Logger, mfaTokenParser MFATokenParser, mfaVerifier MFAVerifier, accessTokenGenerator AccessTokenGenerator, refreshTokenGenerator RefreshTokenGenerator, refreshSessionSaver RefreshSessionSaver, attemptGuard AttemptGuard, accessTokenTTL, refreshTokenTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sessions.NewVerifySessionHandler"
+		var req models.VerifyMFASessionRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded")
+
+		err := validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		claims, err := mfaTokenParser.ParseMFAToken(req.MFAToken)
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			log.Info("invalid mfa token", sl.Err(err))
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("mfa token is expired, log in again"))
+
+			return
+		}
+		if err != nil {
+			log.Info("invalid mfa token", sl.Err(err))
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("invalid mfa token"))
+
+			return
+		}
+
+		keys := []lockout.Key{lockout.User(claims.UserID), lockout.IP(r)}
+
+		retryAfter, err := attemptGuard.Check(r.Context(), keys...)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to check lockout", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to check lockout", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+		if retryAfter > 0 {
+			log.Info("attempt rejected by lockout", slog.Duration("retry_after", retryAfter))
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			w.WriteHeader(429)
+			render.JSON(w, r, resp.Err("too many failed attempts, try again later"))
+
+			return
+		}
+
+		err = mfaVerifier.Verify(r.Context(), claims.UserID, req.Code)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to verify second factor", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, mfa.ErrInvalidCode) || errors.Is(err, storage.ErrTOTPNotEnrolled) {
+			log.Info("failed to verify second factor", sl.Err(err))
+
+			if err := attemptGuard.Fail(r.Context(), keys...); err != nil {
+				log.Error("failed to record failed attempt", sl.Err(err))
+			}
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("invalid code"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to verify second factor", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("second factor verified", slog.Int("id", claims.UserID))
+
+		if err := attemptGuard.Succeed(r.Context(), keys[0]); err != nil {
+			log.Error("failed to reset failed attempts", sl.Err(err))
+		}
+
+		accessToken, err := accessTokenGenerator.GenerateAccessToken(claims.UserID, accessTokenTTL)
+		if err != nil {
+			log.Error("failed to generate access token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		refreshToken, refreshTokenHash, err := refreshTokenGenerator.GenerateRefreshToken()
+		if err != nil {
+			log.Error("failed to generate refresh token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		sessionID, err := refreshSessionSaver.SaveRefreshSession(r.Context(), claims.UserID, refreshTokenHash, time.Now().Add(refreshTokenTTL))
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to save refresh session", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to save refresh session", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("refresh session saved", slog.Int64("session_id", sessionID))
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.SaveSessionResponse{
+			Response:     resp.OK(),
+			ID:           int64(claims.UserID),
+			AccessToken:  accessToken,
+			JWT:          accessToken,
+			RefreshToken: refreshToken,
+		})
+	}
+}
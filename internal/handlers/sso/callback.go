@@ -0,0 +1,254 @@
+package sso
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"time"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type StateUser interface {
+	UseOIDCState(ctx context.Context, state string) (string, string, error)
+}
+
+type CodeExchanger interface {
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (models.OIDCIdentity, error)
+}
+
+type IdentityLinker interface {
+	LinkOIDCIdentity(ctx context.Context, identity models.OIDCIdentity) (models.User, error)
+}
+
+type AccessTokenGenerator interface {
+	GenerateAccessToken(userID int, tokenTTL time.Duration) (string, error)
+}
+
+type RefreshTokenGenerator interface {
+	GenerateRefreshToken() (string, string, error)
+}
+
+type RefreshSessionSaver interface {
+	SaveRefreshSession(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (int64, error)
+}
+
+type MFATokenGenerator interface {
+	GenerateMFAToken(userID int, tokenTTL time.Duration) (string, error)
+}
+
+// NewCallbackHandler finishes the login started by NewLoginHandler and issues
+// the same tokens as a password login.
+func NewCallbackHandler(log *slog.Logger, stateUser StateUser, codeExchanger CodeExchanger, identityLinker IdentityLinker, accessTokenGenerator AccessTokenGenerator, refreshTokenGenerator RefreshTokenGenerator, refreshSessionSaver RefreshSessionSaver, mfaTokenGenerator MFATokenGenerator, accessTokenTTL, refreshTokenTTL, mfaTokenTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sso.NewCallbackHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		cookie, err := r.Cookie(stateCookie)
+		if err != nil || query.Get("state") == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+			log.Info("oidc state doesn't match the cookie")
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid state"))
+
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookie,
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		codeVerifier, nonce, err := stateUser.UseOIDCState(r.Context(), query.Get("state"))
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to use oidc state", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNoOIDCState) {
+			log.Info("failed to use oidc state", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("login attempt is expired or already finished, start again"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to use oidc state", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		if providerErr := query.Get("error"); providerErr != "" {
+			log.Info("identity provider returned an error", slog.String("error", providerErr), slog.String("description", query.Get("error_description")))
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("identity provider denied the login: "+providerErr))
+
+			return
+		}
+
+		identity, err := codeExchanger.Exchange(r.Context(), query.Get("code"), codeVerifier, nonce)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to exchange authorization code", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Info("failed to exchange authorization code", sl.Err(err))
+
+			w.WriteHeader(401)
+			render.JSON(w, r, resp.Err("failed to log in with the identity provider"))
+
+			return
+		}
+
+		log.Info("identity verified", slog.String("issuer", identity.Issuer), slog.String("subject", identity.Subject))
+
+		user, err := identityLinker.LinkOIDCIdentity(r.Context(), identity)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to link identity", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to link identity", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		if user.SuspendedAt != nil {
+			log.Info("suspended user rejected", slog.Int64("id", user.ID))
+
+			w.WriteHeader(403)
+			render.JSON(w, r, resp.Err("account is suspended"))
+
+			return
+		}
+
+		log.Info("user authenticated", slog.Int64("id", user.ID))
+
+		if user.TOTPEnabled {
+			mfaToken, err := mfaTokenGenerator.GenerateMFAToken(int(user.ID), mfaTokenTTL)
+			if err != nil {
+				log.Error("failed to generate mfa token", sl.Err(err))
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+
+			log.Info("second factor required", slog.Int64("id", user.ID))
+
+			render.JSON(w, r, models.SaveSessionResponse{
+				Response:    resp.OK(),
+				ID:          user.ID,
+				MFARequired: true,
+				MFAToken:    mfaToken,
+			})
+
+			return
+		}
+
+		accessToken, err := accessTokenGenerator.GenerateAccessToken(int(user.ID), accessTokenTTL)
+		if err != nil {
+			log.Error("failed to generate access token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		refreshToken, refreshTokenHash, err := refreshTokenGenerator.GenerateRefreshToken()
+		if err != nil {
+			log.Error("failed to generate refresh token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		sessionID, err := refreshSessionSaver.SaveRefreshSession(r.Context(), int(user.ID), refreshTokenHash, time.Now().Add(refreshTokenTTL))
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to save refresh session", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to save refresh session", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("refresh session saved", slog.Int64("session_id", sessionID))
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.SaveSessionResponse{
+			Response:     resp.OK(),
+			ID:           user.ID,
+			AccessToken:  accessToken,
+			JWT:          accessToken,
+			RefreshToken: refreshToken,
+		})
+	}
+}
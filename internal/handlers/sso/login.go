@@ -0,0 +1,76 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"time"
+	resp "todo/internal/api/response"
+	"todo/pkg/logger/sl"
+)
+
+const stateCookie = "oidc_state"
+
+type LoginStarter interface {
+	NewLoginParams() (string, string, string)
+	AuthCodeURL(state, nonce, codeVerifier string) string
+}
+
+type StateSaver interface {
+	SaveOIDCState(ctx context.Context, state, codeVerifier, nonce string, expiresAt time.Time) error
+}
+
+// NewLoginHandler redirects the browser to the identity provider. The state is kept
+// in a cookie as well, so the callback can check it's the same browser.
+func NewLoginHandler(log *slog.Logger, loginStarter LoginStarter, stateSaver StateSaver, stateTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sso.NewLoginHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		state, nonce, codeVerifier := loginStarter.NewLoginParams()
+
+		err := stateSaver.SaveOIDCState(r.Context(), state, codeVerifier, nonce, time.Now().Add(stateTTL))
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to save oidc state", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to save oidc state", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookie,
+			Value:    state,
+			Path:     "/",
+			MaxAge:   int(stateTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		log.Info("oidc login started")
+
+		http.Redirect(w, r, loginStarter.AuthCodeURL(state, nonce, codeVerifier), http.StatusFound)
+	}
+}
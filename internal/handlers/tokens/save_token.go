@@ -0,0 +1,128 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/pkg/logger/sl"
+)
+
+type TokenSaver interface {
+	SavePersonalAccessToken(ctx context.Context, userID int, name, tokenHash string, scopes []string, expiresAt *time.Time) (int64, error)
+}
+
+type TokenGenerator interface {
+	GeneratePersonalAccessToken() (string, string, error)
+}
+
+func NewSaveTokenHandler(log *slog.Logger, tokenSaver TokenSaver, tokenGenerator TokenGenerator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tokens.NewSaveTokenHandler"
+		var req models.SavePersonalAccessTokenRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded", slog.Any("request", req))
+
+		err = validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			log.Info("request validation failed", slog.Time("expires_at", *req.ExpiresAt))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("expires_at must be in the future"))
+
+			return
+		}
+
+		token, tokenHash, err := tokenGenerator.GeneratePersonalAccessToken()
+		if err != nil {
+			log.Error("failed to generate personal access token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		id, err := tokenSaver.SavePersonalAccessToken(r.Context(), userID, req.Name, tokenHash, req.Scopes, req.ExpiresAt)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to save personal access token", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to save personal access token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("personal access token saved", slog.Int64("id", id))
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.SavePersonalAccessTokenResponse{
+			Response: resp.OK(),
+			ID:       id,
+			Token:    token,
+		})
+	}
+}
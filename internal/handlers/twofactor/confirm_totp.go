@@ -0,0 +1,128 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/mfa"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type TOTPConfirmer interface {
+	Confirm(ctx context.Context, userID int, code string) ([]string, error)
+}
+
+func NewConfirmTOTPHandler(log *slog.Logger, totpConfirmer TOTPConfirmer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.twofactor.NewConfirmTOTPHandler"
+		var req models.MFACodeRequest
+		var validationErrs validator.ValidationErrors
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Info("request decoding failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid request"))
+
+			return
+		}
+
+		log.Info("request decoded")
+
+		err = validator.New().Struct(req)
+		if errors.As(err, &validationErrs) {
+			log.Info("request validation failed", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.ValidationErrorsResponse(validationErrs))
+
+			return
+		}
+		if err != nil {
+			log.Error("request validation failed", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		recoveryCodes, err := totpConfirmer.Confirm(r.Context(), userID, req.Code)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to confirm totp", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrTOTPNotEnrolled) {
+			log.Info("failed to confirm totp", sl.Err(err))
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("totp is not enrolled"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrTOTPAlreadyEnabled) {
+			log.Info("failed to confirm totp", sl.Err(err))
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("totp is already enabled"))
+
+			return
+		}
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			log.Info("failed to confirm totp", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("invalid code"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to confirm totp", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("totp enabled", slog.Int("user_id", userID))
+
+		render.JSON(w, r, models.ConfirmTOTPResponse{
+			Response:      resp.OK(),
+			RecoveryCodes: recoveryCodes,
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/logger/sl"
+)
+
+type TOTPEnroller interface {
+	Enroll(ctx context.Context, userID int) (string, string, error)
+}
+
+func NewSaveTOTPHandler(log *slog.Logger, totpEnroller TOTPEnroller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.twofactor.NewSaveTOTPHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		secret, uri, err := totpEnroller.Enroll(r.Context(), userID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to enroll totp", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrTOTPAlreadyEnabled) {
+			log.Info("failed to enroll totp", sl.Err(err))
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("totp is already enabled"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Info("failed to enroll totp", sl.Err(err))
+
+			w.WriteHeader(404)
+			render.JSON(w, r, resp.Err("no such user"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to enroll totp", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("totp enrolled", slog.Int("user_id", userID))
+
+		w.WriteHeader(201)
+		render.JSON(w, r, models.SaveTOTPResponse{
+			Response:        resp.OK(),
+			Secret:          secret,
+			ProvisioningURI: uri,
+		})
+	}
+}
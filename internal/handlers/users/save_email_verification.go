@@ -0,0 +1,81 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"todo/internal/account"
+	resp "todo/internal/api/response"
+	"todo/pkg/logger/sl"
+)
+
+// NewSaveEmailVerificationHandler sends another verification email.
+func NewSaveEmailVerificationHandler(log *slog.Logger, verificationEmailSender VerificationEmailSender) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.users.NewSaveEmailVerificationHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Info("url parameter conversion error", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("user id must be a number"))
+
+			return
+		}
+
+		err = verificationEmailSender.SendVerificationEmail(r.Context(), userID)
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to send verification email", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if errors.Is(err, account.ErrNoEmail) {
+			log.Info("failed to send verification email", sl.Err(err))
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("user has no email"))
+
+			return
+		}
+		if errors.Is(err, account.ErrEmailAlreadyVerified) {
+			log.Info("failed to send verification email", sl.Err(err))
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("email is already verified"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to send verification email", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("verification email sent", slog.Int("id", userID))
+
+		w.WriteHeader(202)
+		render.JSON(w, r, resp.OK())
+	}
+}
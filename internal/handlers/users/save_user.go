@@ -8,22 +8,46 @@ import (
 	"github.com/go-playground/validator/v10"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 	resp "todo/internal/api/response"
+	"todo/internal/lockout"
 	"todo/internal/models"
 	"todo/internal/storage"
+	"todo/pkg/auth"
 	"todo/pkg/logger/sl"
 )
 
 type UserSaver interface {
-	SaveUser(ctx context.Context, username string) (int64, error)
+	SaveUser(ctx context.Context, username, email, passwordHash string) (int64, error)
+}
+
+type VerificationEmailSender interface {
+	SendVerificationEmail(ctx context.Context, userID int) error
+}
+
+type AttemptGuard interface {
+	Check(ctx context.Context, keys ...lockout.Key) (time.Duration, error)
+	Fail(ctx context.Context, keys ...lockout.Key) error
+}
+
+type PasswordHasher interface {
+	HashPassword(password string) (string, error)
 }
 
 type AccessTokenGenerator interface {
 	GenerateAccessToken(userID int, tokenTTL time.Duration) (string, error)
 }
 
-func NewSaveUserHandler(log *slog.Logger, userSaver UserSaver, accessTokenGenerator AccessTokenGenerator, jwtTTL time.Duration) http.HandlerFunc {
+type RefreshTokenGenerator interface {
+	GenerateRefreshToken() (string, string, error)
+}
+
+type RefreshSessionSaver interface {
+	SaveRefreshSession(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (int64, error)
+}
+
+func NewSaveUserHandler(log *slog.Logger, userSaver UserSaver, passwordHasher PasswordHasher, accessTokenGenerator AccessTokenGenerator, refreshTokenGenerator RefreshTokenGenerator, refreshSessionSaver RefreshSessionSaver, verificationEmailSender VerificationEmailSender, attemptGuard AttemptGuard, accessTokenTTL, refreshTokenTTL time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.users.NewSaveUserHandler"
 		var req models.SaveUserRequest
@@ -43,7 +67,7 @@ func NewSaveUserHandler(log *slog.Logger, userSaver UserSaver, accessTokenGenera
 			return
 		}
 
-		log.Info("request decoded", slog.Any("request", req))
+		log.Info("request decoded", slog.String("username", req.Username), slog.String("email", req.Email))
 
 		err := validator.New().Struct(req)
 		if errors.As(err, &validationErrs) {
@@ -63,7 +87,57 @@ func NewSaveUserHandler(log *slog.Logger, userSaver UserSaver, accessTokenGenera
 			return
 		}
 
-		id, err := userSaver.SaveUser(r.Context(), req.Username)
+		retryAfter, err := attemptGuard.Check(r.Context(), lockout.IP(r))
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to check lockout", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to check lockout", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+		if retryAfter > 0 {
+			log.Info("attempt rejected by lockout", slog.Duration("retry_after", retryAfter))
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			w.WriteHeader(429)
+			render.JSON(w, r, resp.Err("too many failed attempts, try again later"))
+
+			return
+		}
+
+		passwordHash, err := passwordHasher.HashPassword(req.Password)
+		if errors.Is(err, auth.ErrPasswordTooLong) {
+			log.Info("failed to hash password", sl.Err(err))
+
+			w.WriteHeader(400)
+			render.JSON(w, r, resp.Err("password must be at most 72 bytes"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to hash password", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		id, err := userSaver.SaveUser(r.Context(), req.Username, req.Email, passwordHash)
 		if errors.Is(err, context.Canceled) {
 			log.Info("connection closed from client side, request cancelled", sl.Err(err))
 
@@ -80,11 +154,27 @@ func NewSaveUserHandler(log *slog.Logger, userSaver UserSaver, accessTokenGenera
 		if errors.Is(err, storage.ErrUserExist) {
 			log.Info("failed to save user", sl.Err(err))
 
+			if err := attemptGuard.Fail(r.Context(), lockout.IP(r)); err != nil {
+				log.Error("failed to record failed attempt", sl.Err(err))
+			}
+
 			w.WriteHeader(409)
 			render.JSON(w, r, resp.Err("user with this username already exists"))
 
 			return
 		}
+		if errors.Is(err, storage.ErrEmailExist) {
+			log.Info("failed to save user", sl.Err(err))
+
+			if err := attemptGuard.Fail(r.Context(), lockout.IP(r)); err != nil {
+				log.Error("failed to record failed attempt", sl.Err(err))
+			}
+
+			w.WriteHeader(409)
+			render.JSON(w, r, resp.Err("user with this email already exists"))
+
+			return
+		}
 		if err != nil {
 			log.Error("failed to save user", sl.Err(err))
 
@@ -96,9 +186,24 @@ func NewSaveUserHandler(log *slog.Logger, userSaver UserSaver, accessTokenGenera
 
 		log.Info("user saved", slog.Int64("id", id))
 
-		jwt, err := accessTokenGenerator.GenerateAccessToken(int(id), jwtTTL)
+		// the user can ask for another email later, so failing to send it doesn't fail the registration
+		if err := verificationEmailSender.SendVerificationEmail(r.Context(), int(id)); err != nil {
+			log.Warn("failed to send verification email", sl.Err(err))
+		}
+
+		accessToken, err := accessTokenGenerator.GenerateAccessToken(int(id), accessTokenTTL)
+		if err != nil {
+			log.Error("failed to generate access token", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		refreshToken, refreshTokenHash, err := refreshTokenGenerator.GenerateRefreshToken()
 		if err != nil {
-			log.Error("failed to generate jwt", sl.Err(err))
+			log.Error("failed to generate refresh token", sl.Err(err))
 
 			w.WriteHeader(500)
 			render.JSON(w, r, resp.Err("internal error"))
@@ -106,11 +211,38 @@ func NewSaveUserHandler(log *slog.Logger, userSaver UserSaver, accessTokenGenera
 			return
 		}
 
+		sessionID, err := refreshSessionSaver.SaveRefreshSession(r.Context(), int(id), refreshTokenHash, time.Now().Add(refreshTokenTTL))
+		if errors.Is(err, context.Canceled) {
+			log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("failed to save refresh session", sl.Err(err))
+
+			w.WriteHeader(504)
+			render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to save refresh session", sl.Err(err))
+
+			w.WriteHeader(500)
+			render.JSON(w, r, resp.Err("internal error"))
+
+			return
+		}
+
+		log.Info("refresh session saved", slog.Int64("session_id", sessionID))
+
 		w.WriteHeader(201)
 		render.JSON(w, r, models.SaveUserResponse{
-			Response: resp.OK(),
-			ID:       id,
-			JWT:      jwt,
+			Response:     resp.OK(),
+			ID:           id,
+			AccessToken:  accessToken,
+			JWT:          accessToken,
+			RefreshToken: refreshToken,
 		})
 	}
 }
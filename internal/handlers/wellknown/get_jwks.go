@@ -0,0 +1,33 @@
+package wellknown
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"todo/pkg/auth"
+)
+
+type JWKSProvider interface {
+	JWKS() auth.JWKS
+}
+
+// NewGetJWKSHandler serves the public keys in the plain JWKS format, so that
+// other services can verify access tokens with any JOSE library.
+func NewGetJWKSHandler(log *slog.Logger, jwksProvider JWKSProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.wellknown.NewGetJWKSHandler"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request-id", middleware.GetReqID(r.Context())),
+		)
+
+		jwks := jwksProvider.JWKS()
+
+		log.Info("got jwks", slog.Int("keys", len(jwks.Keys)))
+
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		render.JSON(w, r, jwks)
+	}
+}
@@ -0,0 +1,267 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+const (
+	KindUsername = "username"
+	KindUser     = "user"
+	KindIP       = "ip"
+)
+
+// Key is what failed attempts are counted against.
+type Key struct {
+	Kind    string
+	Subject string
+}
+
+func Username(username string) Key {
+	return Key{Kind: KindUsername, Subject: strings.ToLower(username)}
+}
+
+func User(userID int) Key {
+	return Key{Kind: KindUser, Subject: strconv.Itoa(userID)}
+}
+
+// IP returns the key of the client address of r.
+func IP(r *http.Request) Key {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return Key{Kind: KindIP, Subject: host}
+}
+
+type Store interface {
+	GetActiveAuthLockouts(ctx context.Context, kinds, subjects []string) ([]models.AuthLockout, error)
+	IncrementAuthFailures(ctx context.Context, kind, subject string, resetBefore time.Time) (int, error)
+	LockAuthSubject(ctx context.Context, kind, subject string, lockedUntil time.Time) error
+	DeleteAuthFailures(ctx context.Context, kind, subject string) (models.AuthLockout, error)
+	GetAuthLockouts(ctx context.Context) ([]models.AuthLockout, error)
+}
+
+type Config struct {
+	// MaxAttempts is the number of failures in a row after which a username or user is locked.
+	MaxAttempts int
+	// IPMaxAttempts is the same for a client address, which may be shared by many users.
+	IPMaxAttempts int
+	// BaseDelay is the first lockout, every next failure doubles it up to MaxDelay.
+	// The failure count starts over after MaxDelay without failures.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// CacheTTL bounds how long a lockout seen by this instance is trusted without
+	// asking the database, so an unlock made by another instance applies within CacheTTL.
+	CacheTTL time.Duration
+}
+
+type cachedLock struct {
+	lockedUntil time.Time
+	checkedAt   time.Time
+}
+
+// Guard counts failed authentication attempts in the database, so that lockouts
+// hold across instances, and remembers active lockouts in memory, so that attempts
+// during a lockout are rejected without a database round trip.
+type Guard struct {
+	log   *slog.Logger
+	store Store
+	cfg   Config
+
+	mu        sync.Mutex
+	locks     map[Key]cachedLock
+	lastSweep time.Time
+}
+
+func NewGuard(log *slog.Logger, store Store, cfg Config) *Guard {
+	return &Guard{
+		log:       log,
+		store:     store,
+		cfg:       cfg,
+		locks:     make(map[Key]cachedLock),
+		lastSweep: time.Now(),
+	}
+}
+
+// Check returns how long the caller has to wait if any of the keys is locked, or zero.
+func (g *Guard) Check(ctx context.Context, keys ...Key) (time.Duration, error) {
+	const op = "lockout.Check"
+	now := time.Now()
+
+	g.mu.Lock()
+	var lockedUntil time.Time
+	for _, k := range keys {
+		lock, ok := g.locks[k]
+		if ok && now.Before(lock.lockedUntil) && now.Sub(lock.checkedAt) < g.cfg.CacheTTL && lock.lockedUntil.After(lockedUntil) {
+			lockedUntil = lock.lockedUntil
+		}
+	}
+	g.mu.Unlock()
+
+	if !lockedUntil.IsZero() {
+		return lockedUntil.Sub(now), nil
+	}
+
+	kinds := make([]string, 0, len(keys))
+	subjects := make([]string, 0, len(keys))
+	for _, k := range keys {
+		kinds = append(kinds, k.Kind)
+		subjects = append(subjects, k.Subject)
+	}
+
+	lockouts, err := g.store.GetActiveAuthLockouts(ctx, kinds, subjects)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, lockout := range lockouts {
+		g.locks[Key{Kind: lockout.Kind, Subject: lockout.Subject}] = cachedLock{lockedUntil: *lockout.LockedUntil, checkedAt: now}
+
+		if lockout.LockedUntil.After(lockedUntil) {
+			lockedUntil = *lockout.LockedUntil
+		}
+	}
+
+	g.sweep(now)
+
+	if lockedUntil.IsZero() {
+		return 0, nil
+	}
+
+	return lockedUntil.Sub(now), nil
+}
+
+// Fail records a failed attempt for every key and locks the keys that ran out of attempts.
+func (g *Guard) Fail(ctx context.Context, keys ...Key) error {
+	const op = "lockout.Fail"
+	now := time.Now()
+
+	for _, k := range keys {
+		failures, err := g.store.IncrementAuthFailures(ctx, k.Kind, k.Subject, now.Add(-g.cfg.MaxDelay))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		maxAttempts := g.cfg.MaxAttempts
+		if k.Kind == KindIP {
+			maxAttempts = g.cfg.IPMaxAttempts
+		}
+		if failures < maxAttempts {
+			continue
+		}
+
+		lockedUntil := now.Add(g.delay(failures - maxAttempts))
+
+		if err := g.store.LockAuthSubject(ctx, k.Kind, k.Subject, lockedUntil); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		g.mu.Lock()
+		g.locks[k] = cachedLock{lockedUntil: lockedUntil, checkedAt: now}
+		g.mu.Unlock()
+
+		g.log.Warn(
+			"authentication locked out",
+			slog.String("kind", k.Kind),
+			slog.String("subject", k.Subject),
+			slog.Int("failures", failures),
+			slog.Time("locked_until", lockedUntil),
+		)
+	}
+
+	return nil
+}
+
+// Succeed forgets the failed attempts of the keys.
+func (g *Guard) Succeed(ctx context.Context, keys ...Key) error {
+	const op = "lockout.Succeed"
+
+	for _, k := range keys {
+		if _, err := g.Unlock(ctx, k); err != nil && !errors.Is(err, storage.ErrNoAuthLockout) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Unlock forgets the failed attempts of the key and returns the lockout it had.
+// It returns storage.ErrNoAuthLockout if there were no failed attempts.
+func (g *Guard) Unlock(ctx context.Context, k Key) (models.AuthLockout, error) {
+	const op = "lockout.Unlock"
+
+	g.mu.Lock()
+	delete(g.locks, k)
+	g.mu.Unlock()
+
+	lockout, err := g.store.DeleteAuthFailures(ctx, k.Kind, k.Subject)
+	if err != nil {
+		return models.AuthLockout{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lockout.LockedUntil != nil && time.Now().Before(*lockout.LockedUntil) {
+		g.log.Info(
+			"authentication unlocked",
+			slog.String("kind", k.Kind),
+			slog.String("subject", k.Subject),
+			slog.Int("failures", lockout.Failures),
+		)
+	}
+
+	return lockout, nil
+}
+
+// Lockouts returns the keys that are locked right now.
+func (g *Guard) Lockouts(ctx context.Context) ([]models.AuthLockout, error) {
+	const op = "lockout.Lockouts"
+
+	lockouts, err := g.store.GetAuthLockouts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lockouts, nil
+}
+
+// delay returns the lockout after extra failures beyond the allowed attempts.
+func (g *Guard) delay(extra int) time.Duration {
+	delay := g.cfg.BaseDelay
+	for range extra {
+		delay *= 2
+		if delay >= g.cfg.MaxDelay {
+			return g.cfg.MaxDelay
+		}
+	}
+
+	return min(delay, g.cfg.MaxDelay)
+}
+
+// sweep drops expired lockouts at most once a minute. g.mu must be held.
+func (g *Guard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < time.Minute {
+		return
+	}
+
+	for k, lock := range g.locks {
+		if now.After(lock.lockedUntil) {
+			delete(g.locks, k)
+		}
+	}
+
+	g.lastSweep = now
+}
@@ -0,0 +1,277 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// memoryStore keeps failed attempts in memory, like the auth_failures table does.
+type memoryStore struct {
+	lockouts map[Key]*models.AuthLockout
+	reads    int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{lockouts: make(map[Key]*models.AuthLockout)}
+}
+
+func (s *memoryStore) GetActiveAuthLockouts(_ context.Context, kinds, subjects []string) ([]models.AuthLockout, error) {
+	var res []models.AuthLockout
+	s.reads++
+
+	for i := range kinds {
+		lockout, ok := s.lockouts[Key{Kind: kinds[i], Subject: subjects[i]}]
+		if ok && lockout.LockedUntil != nil && time.Now().Before(*lockout.LockedUntil) {
+			res = append(res, *lockout)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *memoryStore) IncrementAuthFailures(_ context.Context, kind, subject string, resetBefore time.Time) (int, error) {
+	k := Key{Kind: kind, Subject: subject}
+
+	lockout, ok := s.lockouts[k]
+	if !ok || lockout.LastFailureAt.Before(resetBefore) {
+		lockout = &models.AuthLockout{Kind: kind, Subject: subject}
+		s.lockouts[k] = lockout
+	}
+
+	lockout.Failures++
+	lockout.LastFailureAt = time.Now()
+
+	return lockout.Failures, nil
+}
+
+func (s *memoryStore) LockAuthSubject(_ context.Context, kind, subject string, lockedUntil time.Time) error {
+	s.lockouts[Key{Kind: kind, Subject: subject}].LockedUntil = &lockedUntil
+
+	return nil
+}
+
+func (s *memoryStore) DeleteAuthFailures(_ context.Context, kind, subject string) (models.AuthLockout, error) {
+	k := Key{Kind: kind, Subject: subject}
+
+	lockout, ok := s.lockouts[k]
+	if !ok {
+		return models.AuthLockout{}, storage.ErrNoAuthLockout
+	}
+	delete(s.lockouts, k)
+
+	return *lockout, nil
+}
+
+func (s *memoryStore) GetAuthLockouts(ctx context.Context) ([]models.AuthLockout, error) {
+	var res []models.AuthLockout
+
+	for _, lockout := range s.lockouts {
+		if lockout.LockedUntil != nil {
+			res = append(res, *lockout)
+		}
+	}
+
+	return res, nil
+}
+
+var testConfig = Config{
+	MaxAttempts:   3,
+	IPMaxAttempts: 5,
+	BaseDelay:     time.Minute,
+	MaxDelay:      time.Hour,
+	CacheTTL:      time.Minute,
+}
+
+func newTestGuard(store Store, cfg Config) *Guard {
+	return NewGuard(slog.New(slog.NewTextHandler(io.Discard, nil)), store, cfg)
+}
+
+func TestKeys(t *testing.T) {
+	if got := Username("Alice"); got != (Key{Kind: KindUsername, Subject: "alice"}) {
+		t.Errorf("Username() = %v, want the lowercase username", got)
+	}
+
+	if got := User(7); got != (Key{Kind: KindUser, Subject: "7"}) {
+		t.Errorf("User() = %v", got)
+	}
+
+	tests := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{remoteAddr: "203.0.113.7:52100", want: "203.0.113.7"},
+		{remoteAddr: "[2001:db8::1]:443", want: "2001:db8::1"},
+		{remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/sessions", nil)
+		r.RemoteAddr = tt.remoteAddr
+
+		if got := IP(r); got != (Key{Kind: KindIP, Subject: tt.want}) {
+			t.Errorf("IP(%s) = %v, want %s", tt.remoteAddr, got, tt.want)
+		}
+	}
+}
+
+func TestDelay(t *testing.T) {
+	g := newTestGuard(newMemoryStore(), testConfig)
+
+	tests := []struct {
+		extra int
+		want  time.Duration
+	}{
+		{extra: 0, want: time.Minute},
+		{extra: 1, want: 2 * time.Minute},
+		{extra: 5, want: 32 * time.Minute},
+		{extra: 6, want: time.Hour},
+		{extra: 1000, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := g.delay(tt.extra); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.extra, got, tt.want)
+		}
+	}
+}
+
+func TestGuardLocksAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	g := newTestGuard(store, testConfig)
+	alice := Username("alice")
+
+	for i := 1; i < testConfig.MaxAttempts; i++ {
+		if err := g.Fail(ctx, alice); err != nil {
+			t.Fatalf("Fail(): %v", err)
+		}
+
+		if wait, err := g.Check(ctx, alice); err != nil || wait != 0 {
+			t.Fatalf("Check() after %d failures = %v, %v, want no lockout", i, wait, err)
+		}
+	}
+
+	if err := g.Fail(ctx, alice); err != nil {
+		t.Fatalf("Fail(): %v", err)
+	}
+
+	wait, err := g.Check(ctx, alice)
+	if err != nil || wait <= 0 || wait > testConfig.BaseDelay {
+		t.Fatalf("Check() after %d failures = %v, %v, want up to %v", testConfig.MaxAttempts, wait, err, testConfig.BaseDelay)
+	}
+
+	if err := g.Fail(ctx, alice); err != nil {
+		t.Fatalf("Fail(): %v", err)
+	}
+
+	if wait, _ := g.Check(ctx, alice); wait <= testConfig.BaseDelay {
+		t.Errorf("Check() after another failure = %v, want the lockout doubled", wait)
+	}
+
+	if wait, _ := g.Check(ctx, Username("bob")); wait != 0 {
+		t.Errorf("Check() of another username = %v, want no lockout", wait)
+	}
+
+	if err := g.Succeed(ctx, alice); err != nil {
+		t.Fatalf("Succeed(): %v", err)
+	}
+
+	if wait, _ := g.Check(ctx, alice); wait != 0 {
+		t.Errorf("Check() after Succeed() = %v, want no lockout", wait)
+	}
+}
+
+func TestGuardIPMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	g := newTestGuard(newMemoryStore(), testConfig)
+	ip := Key{Kind: KindIP, Subject: "203.0.113.7"}
+
+	for range testConfig.MaxAttempts {
+		if err := g.Fail(ctx, ip); err != nil {
+			t.Fatalf("Fail(): %v", err)
+		}
+	}
+
+	if wait, _ := g.Check(ctx, ip); wait != 0 {
+		t.Errorf("Check() after %d failures = %v, want no lockout below IPMaxAttempts", testConfig.MaxAttempts, wait)
+	}
+
+	for range testConfig.IPMaxAttempts - testConfig.MaxAttempts {
+		if err := g.Fail(ctx, ip); err != nil {
+			t.Fatalf("Fail(): %v", err)
+		}
+	}
+
+	if wait, _ := g.Check(ctx, ip); wait == 0 {
+		t.Error("Check() after IPMaxAttempts failures = 0, want a lockout")
+	}
+}
+
+func TestGuardCachesLockouts(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	g := newTestGuard(store, testConfig)
+	alice := Username("alice")
+
+	for range testConfig.MaxAttempts {
+		if err := g.Fail(ctx, alice); err != nil {
+			t.Fatalf("Fail(): %v", err)
+		}
+	}
+
+	for range 3 {
+		if wait, _ := g.Check(ctx, alice, Key{Kind: KindIP, Subject: "203.0.113.7"}); wait == 0 {
+			t.Fatal("Check() = 0, want a lockout")
+		}
+	}
+
+	if store.reads != 0 {
+		t.Errorf("Check() of a cached lockout read the store %d times, want none", store.reads)
+	}
+
+	// another instance unlocked it, which this one only notices after CacheTTL
+	delete(store.lockouts, alice)
+	g.locks[alice] = cachedLock{lockedUntil: g.locks[alice].lockedUntil, checkedAt: time.Now().Add(-testConfig.CacheTTL)}
+
+	if wait, _ := g.Check(ctx, alice); wait != 0 {
+		t.Errorf("Check() after CacheTTL = %v, want the unlock seen", wait)
+	}
+}
+
+func TestGuardLockouts(t *testing.T) {
+	ctx := context.Background()
+	g := newTestGuard(newMemoryStore(), testConfig)
+
+	for range testConfig.MaxAttempts {
+		if err := g.Fail(ctx, Username("alice"), User(1)); err != nil {
+			t.Fatalf("Fail(): %v", err)
+		}
+	}
+
+	lockouts, err := g.Lockouts(ctx)
+	if err != nil {
+		t.Fatalf("Lockouts(): %v", err)
+	}
+
+	var kinds []string
+	for _, lockout := range lockouts {
+		kinds = append(kinds, lockout.Kind)
+	}
+	slices.Sort(kinds)
+
+	if !slices.Equal(kinds, []string{KindUser, KindUsername}) {
+		t.Errorf("Lockouts() kinds = %v, want user and username", kinds)
+	}
+
+	if _, err := g.Unlock(ctx, Username("carol")); !errors.Is(err, storage.ErrNoAuthLockout) {
+		t.Errorf("Unlock() of a key without failures = %v, want ErrNoAuthLockout", err)
+	}
+}
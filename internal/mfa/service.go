@@ -0,0 +1,202 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/totp"
+)
+
+const (
+	recoveryCodesCount = 10
+	recoveryCodeBytes  = 10
+	// codes from one step before and after the current one are accepted for clock drift
+	totpSkew = 1
+)
+
+var ErrInvalidCode = errors.New("invalid code")
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type Store interface {
+	GetUser(ctx context.Context, userID int) (models.User, error)
+	SaveTOTPSecret(ctx context.Context, userID int, secret string) error
+	GetTOTP(ctx context.Context, userID int) (models.TOTP, error)
+	EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID int, step int64) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+	DisableTOTP(ctx context.Context, userID int) error
+}
+
+type CodeHasher interface {
+	HashToken(token string) string
+}
+
+// Service manages TOTP second factor: enrollment, confirmation with the first code,
+// recovery codes and verification of codes at login.
+type Service struct {
+	store      Store
+	codeHasher CodeHasher
+	issuer     string
+}
+
+func NewService(store Store, codeHasher CodeHasher, issuer string) *Service {
+	return &Service{
+		store:      store,
+		codeHasher: codeHasher,
+		issuer:     issuer,
+	}
+}
+
+// Enroll generates a new secret for the user and returns it with the provisioning URI.
+// The secret isn't used at login until Confirm.
+func (s *Service) Enroll(ctx context.Context, userID int) (string, string, error) {
+	const op = "mfa.Enroll"
+
+	user, err := s.store.GetUser(ctx, userID)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.TOTPEnabled {
+		return "", "", fmt.Errorf("%s: %w", op, storage.ErrTOTPAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.store.SaveTOTPSecret(ctx, userID, secret); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, totp.ProvisioningURI(s.issuer, user.Username, secret), nil
+}
+
+// Confirm enables TOTP if code matches the enrolled secret and returns new recovery codes.
+func (s *Service) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	const op = "mfa.Confirm"
+
+	userTOTP, err := s.store.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if userTOTP.Enabled {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTOTPAlreadyEnabled)
+	}
+
+	step, ok, err := totp.Validate(userTOTP.Secret, code, time.Now(), totpSkew)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+
+	recoveryCodes := make([]string, 0, recoveryCodesCount)
+	recoveryCodeHashes := make([]string, 0, recoveryCodesCount)
+	for range recoveryCodesCount {
+		recoveryCode, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+		recoveryCodeHashes = append(recoveryCodeHashes, s.codeHasher.HashToken(recoveryCode))
+	}
+
+	if err := s.store.EnableTOTP(ctx, userID, step, recoveryCodeHashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return recoveryCodes, nil
+}
+
+// Verify accepts either a current TOTP code or an unused recovery code.
+// Each of them works only once.
+func (s *Service) Verify(ctx context.Context, userID int, code string) error {
+	const op = "mfa.Verify"
+
+	userTOTP, err := s.store.GetTOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !userTOTP.Enabled {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPNotEnrolled)
+	}
+
+	if len(code) != totp.Digits {
+		err := s.store.UseRecoveryCode(ctx, userID, s.codeHasher.HashToken(normalizeRecoveryCode(code)))
+		if errors.Is(err, storage.ErrNoRecoveryCode) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidCode)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	}
+
+	step, ok, err := totp.Validate(userTOTP.Secret, code, time.Now(), totpSkew)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		return fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+
+	err = s.store.UseTOTPStep(ctx, userID, step)
+	if errors.Is(err, storage.ErrTOTPStepUsed) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidCode)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Disable turns TOTP off after checking code the same way Verify does.
+func (s *Service) Disable(ctx context.Context, userID int, code string) error {
+	const op = "mfa.Disable"
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.store.DisableTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+
+	return code[:len(code)/2] + "-" + code[len(code)/2:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	if len(code) > 0 && !strings.Contains(code, "-") {
+		code = code[:len(code)/2] + "-" + code[len(code)/2:]
+	}
+
+	return code
+}
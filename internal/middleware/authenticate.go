@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/golang-jwt/jwt/v5"
+	"log/slog"
+	"net/http"
+	"strings"
+	resp "todo/internal/api/response"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"todo/pkg/auth"
+	"todo/pkg/logger/sl"
+)
+
+type ctxKey int
+
+const claimsKey ctxKey = iota
+
+type TokenParser interface {
+	ParseToken(token string) (auth.Claims, error)
+}
+
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims auth.Claims) (bool, error)
+}
+
+type TokenHasher interface {
+	HashToken(token string) string
+}
+
+type PersonalAccessTokenUser interface {
+	UsePersonalAccessToken(ctx context.Context, tokenHash string) (models.PersonalAccessToken, error)
+}
+
+type UserGetter interface {
+	GetUser(ctx context.Context, userID int) (models.User, error)
+}
+
+// ClaimsFromContext returns the claims of the access token validated by Authenticate.
+func ClaimsFromContext(ctx context.Context) (auth.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(auth.Claims)
+
+	return claims, ok
+}
+
+// Authenticate validates the bearer token, which is either a JWT access token
+// or a personal access token, rejects revoked tokens and tokens of deleted or
+// suspended users and puts the token claims into the request context.
+func Authenticate(log *slog.Logger, tokenParser TokenParser, revocationChecker RevocationChecker, tokenHasher TokenHasher, personalAccessTokenUser PersonalAccessTokenUser, userGetter UserGetter) func(handler http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		f := func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.Authenticate"
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request-id", middleware.GetReqID(r.Context())),
+			)
+
+			authHeader := r.Header.Get("Authorization")
+
+			authHeaderSplit := strings.Split(authHeader, " ")
+			if len(authHeaderSplit) != 2 || authHeaderSplit[0] != "Bearer" {
+				log.Info("Invalid Authorization header value format")
+
+				w.WriteHeader(401)
+				render.JSON(w, r, resp.Err("invalid Authorization header value format"))
+
+				return
+			}
+
+			token := authHeaderSplit[1]
+			if token == "" {
+				log.Info("bearer token is missing")
+
+				w.WriteHeader(401)
+				render.JSON(w, r, resp.Err("bearer token is missing"))
+
+				return
+			}
+
+			var claims auth.Claims
+
+			if auth.IsPersonalAccessToken(token) {
+				pat, err := personalAccessTokenUser.UsePersonalAccessToken(r.Context(), tokenHasher.HashToken(token))
+				if errors.Is(err, context.Canceled) {
+					log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+					return
+				}
+				if errors.Is(err, context.DeadlineExceeded) {
+					log.Warn("failed to check personal access token", sl.Err(err))
+
+					w.WriteHeader(504)
+					render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+					return
+				}
+				if errors.Is(err, storage.ErrNoPersonalAccessToken) {
+					log.Info("invalid personal access token", sl.Err(err))
+
+					w.WriteHeader(401)
+					render.JSON(w, r, resp.Err("invalid token"))
+
+					return
+				}
+				if err != nil {
+					log.Error("failed to check personal access token", sl.Err(err))
+
+					w.WriteHeader(500)
+					render.JSON(w, r, resp.Err("internal error"))
+
+					return
+				}
+
+				log.Info("personal access token validated", slog.Int64("sub", pat.UserID), slog.Int64("pat_id", pat.ID))
+
+				claims = auth.Claims{
+					UserID:                int(pat.UserID),
+					PersonalAccessTokenID: pat.ID,
+					Scopes:                pat.Scopes,
+					IssuedAt:              pat.CreatedAt,
+				}
+				if pat.ExpiresAt != nil {
+					claims.ExpiresAt = *pat.ExpiresAt
+				}
+			} else {
+				var err error
+				claims, err = tokenParser.ParseToken(token)
+				if errors.Is(err, jwt.ErrTokenExpired) {
+					log.Info("invalid token", sl.Err(err))
+
+					w.WriteHeader(401)
+					render.JSON(w, r, resp.Err("token is expired"))
+
+					return
+				}
+				if err != nil {
+					log.Info("invalid token", sl.Err(err))
+
+					w.WriteHeader(401)
+					render.JSON(w, r, resp.Err("invalid token"))
+
+					return
+				}
+
+				revoked, err := revocationChecker.IsRevoked(r.Context(), claims)
+				if errors.Is(err, context.Canceled) {
+					log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+					return
+				}
+				if errors.Is(err, context.DeadlineExceeded) {
+					log.Warn("failed to check token revocation", sl.Err(err))
+
+					w.WriteHeader(504)
+					render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+					return
+				}
+				if err != nil {
+					log.Error("failed to check token revocation", sl.Err(err))
+
+					w.WriteHeader(500)
+					render.JSON(w, r, resp.Err("internal error"))
+
+					return
+				}
+				if revoked {
+					log.Info("revoked token used", slog.Int("sub", claims.UserID))
+
+					w.WriteHeader(401)
+					render.JSON(w, r, resp.Err("token is revoked"))
+
+					return
+				}
+
+				log.Info("token validated", slog.Int("sub", claims.UserID))
+			}
+
+			user, err := userGetter.GetUser(r.Context(), claims.UserID)
+			if errors.Is(err, context.Canceled) {
+				log.Info("connection closed from client side, request cancelled", sl.Err(err))
+
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Warn("failed to get user", sl.Err(err))
+
+				w.WriteHeader(504)
+				render.JSON(w, r, resp.Err("request took too long to process, try again later"))
+
+				return
+			}
+			if errors.Is(err, storage.ErrUserNotFound) {
+				log.Info("token of a deleted user used", slog.Int("sub", claims.UserID))
+
+				w.WriteHeader(401)
+				render.JSON(w, r, resp.Err("invalid token"))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to get user", sl.Err(err))
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+			if user.SuspendedAt != nil {
+				log.Info("suspended user rejected", slog.Int("sub", claims.UserID))
+
+				w.WriteHeader(403)
+				render.JSON(w, r, resp.Err("account is suspended"))
+
+				return
+			}
+
+			claims.Role = user.Role
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey, claims)))
+		}
+		return http.HandlerFunc(f)
+	}
+}
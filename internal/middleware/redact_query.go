@@ -0,0 +1,33 @@
+package middleware
+
+import "net/http"
+
+const redacted = "REDACTED"
+
+// RedactQuery hides the values of the query params in r.RequestURI, so that a request
+// logger running after it doesn't write secrets like the calendar feed token to the log.
+// Handlers read the query from r.URL, which is left as it is.
+func RedactQuery(params ...string) func(handler http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		f := func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			found := false
+
+			for _, param := range params {
+				if query.Has(param) {
+					query.Set(param, redacted)
+					found = true
+				}
+			}
+
+			if found {
+				r = r.WithContext(r.Context())
+				r.RequestURI = r.URL.EscapedPath() + "?" + query.Encode()
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(f)
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		uri        string
+		wantURI    string
+		wantTokens []string
+	}{
+		{
+			name:       "token",
+			uri:        "/users/1/calendar.ics?token=secret&component=VTODO",
+			wantURI:    "/users/1/calendar.ics?component=VTODO&token=REDACTED",
+			wantTokens: []string{"secret"},
+		},
+		{
+			name:       "repeated token",
+			uri:        "/users/1/calendar.ics?token=a&token=b",
+			wantURI:    "/users/1/calendar.ics?token=REDACTED",
+			wantTokens: []string{"a", "b"},
+		},
+		{
+			name:    "no token",
+			uri:     "/users/1/calendar.ics?component=VTODO",
+			wantURI: "/users/1/calendar.ics?component=VTODO",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotURI string
+			var gotTokens []string
+
+			handler := RedactQuery("token")(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				gotURI = r.RequestURI
+				gotTokens = r.URL.Query()["token"]
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.uri, nil))
+
+			if gotURI != tt.wantURI {
+				t.Errorf("RequestURI = %q, want %q", gotURI, tt.wantURI)
+			}
+
+			// the handler still gets the token
+			if len(gotTokens) != len(tt.wantTokens) || (len(gotTokens) > 0 && gotTokens[0] != tt.wantTokens[0]) {
+				t.Errorf("URL token = %v, want %v", gotTokens, tt.wantTokens)
+			}
+		})
+	}
+}
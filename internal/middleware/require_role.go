@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	resp "todo/internal/api/response"
+)
+
+// RequireRole rejects users without role. It must run after Authenticate.
+func RequireRole(log *slog.Logger, role string) func(handler http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		f := func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.RequireRole"
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request-id", middleware.GetReqID(r.Context())),
+			)
+
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				log.Error("no token claims in context, Authenticate must run before RequireRole")
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+
+			if claims.Role != role {
+				log.Info("user lacks required role", slog.String("role", role), slog.Int("sub", claims.UserID))
+
+				w.WriteHeader(403)
+				render.JSON(w, r, resp.Err("you have no access to this resource"))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(f)
+	}
+}
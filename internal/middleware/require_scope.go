@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	resp "todo/internal/api/response"
+)
+
+// RequireScope rejects tokens that don't grant scope. It must run after Authenticate.
+func RequireScope(log *slog.Logger, scope string) func(handler http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		f := func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.RequireScope"
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request-id", middleware.GetReqID(r.Context())),
+			)
+
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				log.Error("no token claims in context, Authenticate must run before RequireScope")
+
+				w.WriteHeader(500)
+				render.JSON(w, r, resp.Err("internal error"))
+
+				return
+			}
+
+			if !claims.HasScope(scope) {
+				log.Info("token lacks required scope", slog.String("scope", scope), slog.Int("sub", claims.UserID))
+
+				w.WriteHeader(403)
+				render.JSON(w, r, resp.Err("token has no "+scope+" scope"))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(f)
+	}
+}
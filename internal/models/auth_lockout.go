@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type AuthLockout struct {
+	Kind          string     `json:"kind"`
+	Subject       string     `json:"subject"`
+	Failures      int        `json:"failures"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+}
@@ -2,10 +2,115 @@ package models
 
 import "time"
 
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+const (
+	NoteStatusOpen       = "open"
+	NoteStatusInProgress = "in_progress"
+	NoteStatusDone       = "done"
+)
+
+// Note is a todo item. Priority goes from 0 (none) to 3 (high),
+// CompletedAt is set while Status is NoteStatusDone, DeletedAt while the note is in the trash.
+// ItemsDone of ItemsTotal checklist items are done. A note with an RRule recurs,
+// completing it creates its next occurrence, see pkg/rrule.
 type Note struct {
-	ID        int64     `json:"id"`
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content,omitempty"`
+	Status      string     `json:"status"`
+	Priority    int        `json:"priority"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Tags        []string   `json:"tags"`
+	ProjectID   *int64     `json:"project_id,omitempty"`
+	RRule       string     `json:"rrule,omitempty"`
+	Timezone    string     `json:"timezone,omitempty"`
+	ItemsDone   int        `json:"items_done"`
+	ItemsTotal  int        `json:"items_total"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	// Version goes up with every change of the note, it is also sent as the ETag.
+	Version int64 `json:"version"`
+}
+
+// The fields of a note as they are named in JSON, PatchNote takes the changed ones.
+const (
+	NoteFieldTitle    = "title"
+	NoteFieldContent  = "content"
+	NoteFieldStatus   = "status"
+	NoteFieldPriority = "priority"
+	NoteFieldDueAt    = "due_at"
+	NoteFieldTags     = "tags"
+	NoteFieldProject  = "project_id"
+	NoteFieldRRule    = "rrule"
+	NoteFieldTimezone = "timezone"
+)
+
+// NoteOperationResult is the outcome of one operation of a batch, Err is nil on success.
+type NoteOperationResult struct {
+	ID      int64
+	Version int64
+	Err     error
+}
+
+// NoteItem is a checklist item of a note, items are ordered by Position.
+type NoteItem struct {
+	ID          int64      `json:"id"`
+	Position    int        `json:"position"`
+	Text        string     `json:"text"`
+	Done        bool       `json:"done"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NoteRevision is the title and content of a note as they were saved at CreatedAt.
+type NoteRevision struct {
+	Revision  int       `json:"revision"`
 	Title     string    `json:"title"`
-	Content   string    `json:"content,omitempty"`
+	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NoteSearchResult is a note found by a search. HighlightedTitle and Snippet
+// are HTML escaped with the matches wrapped in <mark> tags.
+type NoteSearchResult struct {
+	Note             Note    `json:"note"`
+	Rank             float32 `json:"rank"`
+	HighlightedTitle string  `json:"highlighted_title"`
+	Snippet          string  `json:"snippet"`
+}
+
+// NoteSortFields are the fields notes can be sorted by.
+var NoteSortFields = []string{"created_at", "updated_at", "title", "priority", "due_at"}
+
+// SortKey is one field of a sort order.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// NoteFilter narrows GetNotes down, zero fields don't filter.
+type NoteFilter struct {
+	Statuses  []string
+	Priority  *int
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	// CreatedAfter and UpdatedSince are exclusive and inclusive respectively.
+	CreatedAfter  *time.Time
+	UpdatedSince  *time.Time
+	TitleContains string
+	// Tags match notes with any of them or, with TagModeAll, with all of them.
+	Tags    []string
+	TagMode string
+	// ProjectID keeps the notes of a project, and of its subprojects with Subprojects.
+	// Inbox keeps the notes without a project.
+	ProjectID   *int64
+	Subprojects bool
+	Inbox       bool
 }
@@ -0,0 +1,9 @@
+package models
+
+type OIDCIdentity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
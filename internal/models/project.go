@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	// ProjectNotesInbox and ProjectNotesTrash say what happens to the notes
+	// of a deleted project and its subprojects.
+	ProjectNotesInbox = "inbox"
+	ProjectNotesTrash = "trash"
+)
+
+// Project groups notes, projects nest through ParentID. Top-level projects have no parent.
+type Project struct {
+	ID        int64     `json:"id"`
+	ParentID  *int64    `json:"parent_id,omitempty"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
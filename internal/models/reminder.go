@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+const (
+	ReminderChannelWebhook = "webhook"
+	ReminderChannelEmail   = "email"
+	ReminderChannelInApp   = "inapp"
+)
+
+// Reminder fires at At, or Offset from the due_at of its note, through Channel.
+// FireAt is unset while the note of an offset reminder has no due_at. A failed
+// delivery is retried with backoff until FailedAt is set.
+type Reminder struct {
+	ID         int64      `json:"id"`
+	NoteID     int64      `json:"note_id"`
+	At         *time.Time `json:"at,omitempty"`
+	Offset     string     `json:"offset,omitempty"`
+	Channel    string     `json:"channel"`
+	WebhookURL string     `json:"webhook_url,omitempty"`
+	FireAt     *time.Time `json:"fire_at,omitempty"`
+	Attempts   int        `json:"attempts"`
+	LastError  string     `json:"last_error,omitempty"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+	FailedAt   *time.Time `json:"failed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// DueReminder is a reminder claimed by the scheduler, with what its channel needs to send it.
+type DueReminder struct {
+	Reminder
+	UserID    int64
+	Username  string
+	Email     *string
+	NoteTitle string
+	NoteDueAt *time.Time
+}
+
+// ReminderResult is the outcome of firing a reminder. A failed one is retried
+// at RetryAt, or given up when RetryAt is zero.
+type ReminderResult struct {
+	Err     error
+	RetryAt time.Time
+}
+
+// Notification is a message of the in-app inbox.
+type Notification struct {
+	ID        int64      `json:"id"`
+	NoteID    *int64     `json:"note_id,omitempty"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -1,9 +1,125 @@
 package models
 
+import "time"
+
+// Request is a note. An omitted status is NoteStatusOpen.
+// A recurring note needs DueAt, its RRule is expanded in Timezone, which defaults to UTC.
 type Request struct {
-	Title   string `json:"title" validate:"required"`
-	Content string `json:"content,omitempty"`
+	Title    string     `json:"title" validate:"required"`
+	Content  string     `json:"content,omitempty"`
+	Status   string     `json:"status,omitempty" validate:"omitempty,oneof=open in_progress done"`
+	Priority int        `json:"priority,omitempty" validate:"min=0,max=3"`
+	DueAt    *time.Time `json:"due_at,omitempty" validate:"required_with=RRule"`
+	Tags     []string   `json:"tags,omitempty" validate:"max=20,dive,required,max=50"`
+	// ProjectID is nil for notes in the inbox.
+	ProjectID *int64 `json:"project_id,omitempty"`
+	RRule     string `json:"rrule,omitempty" validate:"max=500"`
+	Timezone  string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+}
+
+const (
+	NoteOpCreate   = "create"
+	NoteOpUpdate   = "update"
+	NoteOpDelete   = "delete"
+	NoteOpComplete = "complete"
+)
+
+// NoteBatchRequest is a list of note operations run in one transaction. A failed
+// operation rolls back all of them, unless BestEffort is set.
+type NoteBatchRequest struct {
+	BestEffort bool            `json:"best_effort,omitempty"`
+	Operations []NoteOperation `json:"operations" validate:"required,min=1,max=100"`
+}
+
+// NoteOperation is one operation of a batch. NoteID is set for all but create,
+// Note for create and update. A non-zero Version works like If-Match.
+// Items makes complete complete the checklist items too.
+type NoteOperation struct {
+	Op      string   `json:"op"`
+	NoteID  int      `json:"note_id,omitempty"`
+	Version int64    `json:"version,omitempty"`
+	Note    *Request `json:"note,omitempty"`
+	Items   bool     `json:"items,omitempty"`
+}
+
+type ProjectRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	ParentID *int64 `json:"parent_id,omitempty"`
+}
+
+// MoveNoteRequest moves a note to a project, or to the inbox without ProjectID.
+type MoveNoteRequest struct {
+	ProjectID *int64 `json:"project_id"`
+}
+
+type NoteItemRequest struct {
+	Text string `json:"text" validate:"required,max=500"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// ReorderNoteItemsRequest lists all items of a note in their new order.
+type ReorderNoteItemsRequest struct {
+	ItemIDs []int64 `json:"item_ids" validate:"required"`
+}
+
+// ReminderRequest sets either At or Offset, a duration like "-30m" from the due_at of the note.
+type ReminderRequest struct {
+	At         *time.Time `json:"at,omitempty" validate:"required_without=Offset,excluded_with=Offset"`
+	Offset     string     `json:"offset,omitempty"`
+	Channel    string     `json:"channel" validate:"required,oneof=webhook email inapp"`
+	WebhookURL string     `json:"webhook_url,omitempty" validate:"required_if=Channel webhook,excluded_unless=Channel webhook"`
+}
+
+type TagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
 }
 type SaveUserRequest struct {
 	Username string `json:"username" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type SaveSessionRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type DeleteSessionRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type SavePersonalAccessTokenRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=notes:read notes:write"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type VerifyMFASessionRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
 }
@@ -1,5 +1,7 @@
 package models
 
+import "todo/pkg/diff"
+
 type Response struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
@@ -11,7 +13,75 @@ type GetNoteResponse struct {
 
 type GetNotesResponse struct {
 	Response
-	Notes []Note `json:"notes,omitempty"`
+	Notes      []Note `json:"notes"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type GetTrashResponse struct {
+	Response
+	Notes []Note `json:"notes"`
+}
+
+type GetNoteRevisionsResponse struct {
+	Response
+	Revisions []NoteRevision `json:"revisions"`
+}
+
+type GetNoteRevisionResponse struct {
+	Response
+	Revision NoteRevision `json:"revision"`
+}
+
+// DiffNoteRevisionsResponse holds the line edits that turn revision From into revision To.
+type DiffNoteRevisionsResponse struct {
+	Response
+	From    int         `json:"from"`
+	To      int         `json:"to"`
+	Title   []diff.Line `json:"title"`
+	Content []diff.Line `json:"content"`
+}
+
+// NoteBatchResult is what one operation of a batch would have got as a request of its own.
+type NoteBatchResult struct {
+	Status  int    `json:"status"`
+	ID      int64  `json:"id,omitempty"`
+	Version int64  `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type NoteBatchResponse struct {
+	Response
+	Results []NoteBatchResult `json:"results"`
+}
+
+type SaveNoteItemResponse struct {
+	Response
+	ID int64 `json:"id"`
+}
+
+type GetNoteItemsResponse struct {
+	Response
+	Items []NoteItem `json:"items"`
+}
+
+type SaveReminderResponse struct {
+	Response
+	ID int64 `json:"id"`
+}
+
+type GetRemindersResponse struct {
+	Response
+	Reminders []Reminder `json:"reminders"`
+}
+
+type GetNotificationsResponse struct {
+	Response
+	Notifications []Notification `json:"notifications"`
+}
+
+type SearchNotesResponse struct {
+	Response
+	Results []NoteSearchResult `json:"results"`
 }
 
 type SaveNoteResponse struct {
@@ -19,8 +89,93 @@ type SaveNoteResponse struct {
 	ID int64 `json:"id"`
 }
 
+type SaveTagResponse struct {
+	Response
+	ID int64 `json:"id"`
+}
+
+type SaveProjectResponse struct {
+	Response
+	ID int64 `json:"id"`
+}
+
+type GetProjectResponse struct {
+	Response
+	Project Project `json:"project"`
+}
+
+type GetProjectsResponse struct {
+	Response
+	Projects []Project `json:"projects"`
+}
+
+type GetTagsResponse struct {
+	Response
+	Tags []Tag `json:"tags"`
+}
+
+// SaveUserResponse has the access token in JWT too, for clients from before refresh tokens.
 type SaveUserResponse struct {
 	Response
-	ID  int64  `json:"id"`
-	JWT string `json:"jwt"`
+	ID          int64  `json:"id"`
+	AccessToken string `json:"access_token"`
+	// Deprecated: JWT is AccessToken.
+	JWT          string `json:"jwt"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SaveSessionResponse has the access token in JWT too, for clients from before refresh tokens.
+type SaveSessionResponse struct {
+	Response
+	ID          int64  `json:"id"`
+	AccessToken string `json:"access_token,omitempty"`
+	// Deprecated: JWT is AccessToken.
+	JWT          string `json:"jwt,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+type SavePersonalAccessTokenResponse struct {
+	Response
+	ID    int64  `json:"id"`
+	Token string `json:"token"`
+}
+
+type GetPersonalAccessTokensResponse struct {
+	Response
+	Tokens []PersonalAccessToken `json:"tokens"`
+}
+
+type SaveTOTPResponse struct {
+	Response
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type ConfirmTOTPResponse struct {
+	Response
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type GetUsersResponse struct {
+	Response
+	Users []User `json:"users"`
+}
+
+type GetAuthLockoutsResponse struct {
+	Response
+	Lockouts []AuthLockout `json:"lockouts"`
+}
+
+type SaveCalendarTokenResponse struct {
+	Response
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+type ImportCalendarResponse struct {
+	Response
+	IDs     []int64 `json:"ids"`
+	Skipped int     `json:"skipped"`
 }
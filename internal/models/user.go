@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type User struct {
+	ID              int64      `json:"id"`
+	Username        string     `json:"username"`
+	Email           *string    `json:"email,omitempty"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PasswordHash    string     `json:"-"`
+	TOTPEnabled     bool       `json:"totp_enabled"`
+	Role            string     `json:"role"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type TOTP struct {
+	Secret   string
+	Enabled  bool
+	LastStep *int64
+}
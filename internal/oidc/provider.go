@@ -0,0 +1,94 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"todo/internal/models"
+)
+
+var (
+	ErrNoIDToken     = errors.New("no id_token in token response")
+	ErrNonceMismatch = errors.New("id_token nonce doesn't match")
+)
+
+// Provider runs the authorization code flow with PKCE against an external
+// OpenID Connect identity provider.
+type Provider struct {
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewProvider fetches the provider configuration from the issuer's discovery document.
+func NewProvider(ctx context.Context, issuer, clientID, clientSecret, redirectURL string) (*Provider, error) {
+	const op = "oidc.NewProvider"
+
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Provider{
+		oauth2: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+	}, nil
+}
+
+// NewLoginParams returns fresh state, nonce and PKCE code verifier for one login attempt.
+func (p *Provider) NewLoginParams() (string, string, string) {
+	return oauth2.GenerateVerifier(), oauth2.GenerateVerifier(), oauth2.GenerateVerifier()
+}
+
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	return p.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier))
+}
+
+// Exchange trades the authorization code for tokens and returns the identity
+// from the verified ID token.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (models.OIDCIdentity, error) {
+	const op = "oidc.Exchange"
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return models.OIDCIdentity{}, fmt.Errorf("%s: %w", op, ErrNoIDToken)
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if idToken.Nonce != nonce {
+		return models.OIDCIdentity{}, fmt.Errorf("%s: %w", op, ErrNonceMismatch)
+	}
+
+	if err := idToken.Claims(&claims); err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.OIDCIdentity{
+		Issuer:            idToken.Issuer,
+		Subject:           idToken.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
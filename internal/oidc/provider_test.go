@@ -0,0 +1,302 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"todo/internal/models"
+)
+
+const (
+	testClientID     = "todo"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://localhost/sso/callback"
+	testCode         = "authorization-code"
+	testKeyID        = "test-key"
+)
+
+// mockIssuer is an identity provider that serves the discovery document, the JWKS
+// and a token endpoint issuing an ID token for the last authorization request.
+type mockIssuer struct {
+	*httptest.Server
+	t   *testing.T
+	key *rsa.PrivateKey
+
+	mu            sync.Mutex
+	codeChallenge string
+	nonce         string
+	// claims override the ID token claims, a nil value removes the claim
+	claims    map[string]any
+	noIDToken bool
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+
+	m := &mockIssuer{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("GET /jwks", m.jwks)
+	mux.HandleFunc("POST /token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func (m *mockIssuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{
+		"issuer":                                m.URL,
+		"authorization_endpoint":                m.URL + "/authorize",
+		"token_endpoint":                        m.URL + "/token",
+		"jwks_uri":                              m.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (m *mockIssuer) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": testKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize plays the user logging in at the provider: it records the PKCE challenge
+// and nonce of the login URL the way the provider's authorization endpoint would.
+func (m *mockIssuer) authorize(authCodeURL string) url.Values {
+	m.t.Helper()
+
+	u, err := url.Parse(authCodeURL)
+	if err != nil {
+		m.t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	query := u.Query()
+	m.codeChallenge = query.Get("code_challenge")
+	m.nonce = query.Get("nonce")
+
+	return query
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if clientID != testClientID || clientSecret != testClientSecret ||
+		r.PostForm.Get("code") != testCode ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != m.codeChallenge {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+
+		return
+	}
+
+	response := map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	}
+
+	if !m.noIDToken {
+		now := time.Now()
+		claims := jwt.MapClaims{
+			"iss":                m.URL,
+			"aud":                testClientID,
+			"sub":                "subject-1",
+			"iat":                now.Unix(),
+			"exp":                now.Add(time.Hour).Unix(),
+			"nonce":              m.nonce,
+			"email":              "alice@example.com",
+			"email_verified":     true,
+			"preferred_username": "alice",
+		}
+		for name, value := range m.claims {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		idToken.Header["kid"] = testKeyID
+
+		rawIDToken, err := idToken.SignedString(m.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		response["id_token"] = rawIDToken
+	}
+
+	writeJSON(w, response)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestProviderLogin(t *testing.T) {
+	issuer := newMockIssuer(t)
+	ctx := context.Background()
+
+	provider, err := NewProvider(ctx, issuer.URL, testClientID, testClientSecret, testRedirectURL)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	state, nonce, codeVerifier := provider.NewLoginParams()
+	query := issuer.authorize(provider.AuthCodeURL(state, nonce, codeVerifier))
+
+	for name, want := range map[string]string{
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"response_type":         "code",
+		"state":                 state,
+		"nonce":                 nonce,
+		"code_challenge_method": "S256",
+	} {
+		if got := query.Get(name); got != want {
+			t.Errorf("AuthCodeURL() %s = %q, want %q", name, got, want)
+		}
+	}
+
+	identity, err := provider.Exchange(ctx, testCode, codeVerifier, nonce)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	want := models.OIDCIdentity{
+		Issuer:            issuer.URL,
+		Subject:           "subject-1",
+		Email:             "alice@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "alice",
+	}
+	if identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", identity, want)
+	}
+}
+
+func TestProviderExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		// change sets up the issuer and returns the code verifier and nonce to exchange with
+		change  func(m *mockIssuer, codeVerifier, nonce string) (string, string)
+		wantErr error
+	}{
+		{
+			name: "nonce of another login",
+			change: func(_ *mockIssuer, codeVerifier, _ string) (string, string) {
+				return codeVerifier, "another nonce"
+			},
+			wantErr: ErrNonceMismatch,
+		},
+		{
+			name: "wrong code verifier",
+			change: func(_ *mockIssuer, _, nonce string) (string, string) {
+				return "another verifier", nonce
+			},
+		},
+		{
+			name: "no id token",
+			change: func(m *mockIssuer, codeVerifier, nonce string) (string, string) {
+				m.noIDToken = true
+
+				return codeVerifier, nonce
+			},
+			wantErr: ErrNoIDToken,
+		},
+		{
+			name: "token for another client",
+			change: func(m *mockIssuer, codeVerifier, nonce string) (string, string) {
+				m.claims = map[string]any{"aud": "another client"}
+
+				return codeVerifier, nonce
+			},
+		},
+		{
+			name: "expired token",
+			change: func(m *mockIssuer, codeVerifier, nonce string) (string, string) {
+				m.claims = map[string]any{"exp": time.Now().Add(-time.Minute).Unix()}
+
+				return codeVerifier, nonce
+			},
+		},
+		{
+			name: "token from another issuer",
+			change: func(m *mockIssuer, codeVerifier, nonce string) (string, string) {
+				m.claims = map[string]any{"iss": "https://evil.example.com"}
+
+				return codeVerifier, nonce
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newMockIssuer(t)
+			ctx := context.Background()
+
+			provider, err := NewProvider(ctx, issuer.URL, testClientID, testClientSecret, testRedirectURL)
+			if err != nil {
+				t.Fatalf("NewProvider() error = %v", err)
+			}
+
+			state, nonce, codeVerifier := provider.NewLoginParams()
+			issuer.authorize(provider.AuthCodeURL(state, nonce, codeVerifier))
+			codeVerifier, nonce = tt.change(issuer, codeVerifier, nonce)
+
+			_, err = provider.Exchange(ctx, testCode, codeVerifier, nonce)
+			if err == nil {
+				t.Fatalf("Exchange() error = nil, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Exchange() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
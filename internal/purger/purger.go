@@ -0,0 +1,54 @@
+package purger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"todo/pkg/logger/sl"
+)
+
+type Store interface {
+	PurgeDeletedNotes(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// Purger deletes for good the notes that have been in the trash for longer than retention.
+type Purger struct {
+	log       *slog.Logger
+	store     Store
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurger(log *slog.Logger, store Store, retention, interval time.Duration) *Purger {
+	return &Purger{
+		log:       log,
+		store:     store,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run purges the trash right away and then every interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	const op = "purger.Run"
+
+	log := p.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.store.PurgeDeletedNotes(ctx, time.Now().Add(-p.retention))
+		if err != nil {
+			log.Error("failed to purge trash", sl.Err(err))
+		} else if purged > 0 {
+			log.Info("trash purged", slog.Int64("count", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo/internal/models"
+)
+
+// ErrUndeliverable is returned by a channel for reminders retrying won't help with,
+// the scheduler gives up on them right away.
+var ErrUndeliverable = errors.New("reminder can't be delivered")
+
+// Notify puts a notification about the reminder in the inbox of its user. It writes in
+// the transaction that records the delivery, so the notification and the reminder being
+// sent are committed together.
+type Notify = func(ctx context.Context, title, body string) error
+
+// Channel delivers fired reminders, the scheduler picks one by models.ReminderChannel*.
+// Only in-app notifications are delivered exactly once, through notify. Webhooks and
+// emails leave the server before the delivery is recorded, if that fails they are
+// sent again: they are delivered at least once.
+type Channel interface {
+	Send(ctx context.Context, reminder models.DueReminder, notify Notify) error
+}
+
+// message returns the subject and text that tell the user about the reminder.
+func message(reminder models.DueReminder) (string, string) {
+	subject := "Reminder: " + reminder.NoteTitle
+	if reminder.NoteDueAt == nil {
+		return subject, reminder.NoteTitle
+	}
+
+	return subject, reminder.NoteTitle + " is due " + reminder.NoteDueAt.UTC().Format(time.RFC1123)
+}
@@ -0,0 +1,39 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"todo/internal/models"
+	"todo/pkg/mailer"
+)
+
+// Email mails reminders to the address of the user.
+type Email struct {
+	mailer mailer.Mailer
+}
+
+func NewEmail(mailer mailer.Mailer) *Email {
+	return &Email{
+		mailer: mailer,
+	}
+}
+
+func (c *Email) Send(ctx context.Context, reminder models.DueReminder, _ Notify) error {
+	const op = "reminders.Email.Send"
+
+	if reminder.Email == nil {
+		return fmt.Errorf("%s: %w: user has no email", op, ErrUndeliverable)
+	}
+
+	subject, body := message(reminder)
+
+	if err := c.mailer.Send(ctx, mailer.Message{
+		To:      *reminder.Email,
+		Subject: subject,
+		Body:    "Hi " + reminder.Username + ",\n\n" + body + ".\n",
+	}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
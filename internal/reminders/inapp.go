@@ -0,0 +1,26 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"todo/internal/models"
+)
+
+// InApp puts reminders in the notifications inbox of the user.
+type InApp struct{}
+
+func NewInApp() *InApp {
+	return &InApp{}
+}
+
+func (c *InApp) Send(ctx context.Context, reminder models.DueReminder, notify Notify) error {
+	const op = "reminders.InApp.Send"
+
+	subject, body := message(reminder)
+
+	if err := notify(ctx, subject, body); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
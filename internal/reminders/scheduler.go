@@ -0,0 +1,124 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"todo/internal/models"
+	"todo/pkg/logger/sl"
+)
+
+type Store interface {
+	FireReminder(ctx context.Context, fire func(ctx context.Context, reminder models.DueReminder, notify Notify) models.ReminderResult) (bool, error)
+}
+
+type Config struct {
+	// PollInterval is how often the database is checked for due reminders.
+	PollInterval time.Duration
+	// DeliveryTimeout bounds a single delivery.
+	DeliveryTimeout time.Duration
+	// MaxAttempts is the number of deliveries after which a reminder is given up.
+	// BaseDelay is the wait before the first retry, every next one doubles it up to MaxDelay.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Scheduler fires due reminders through their channels. Reminders are claimed
+// in the database, so any number of instances can run a scheduler.
+type Scheduler struct {
+	log      *slog.Logger
+	store    Store
+	channels map[string]Channel
+	cfg      Config
+}
+
+func NewScheduler(log *slog.Logger, store Store, channels map[string]Channel, cfg Config) *Scheduler {
+	return &Scheduler{
+		log:      log,
+		store:    store,
+		channels: channels,
+		cfg:      cfg,
+	}
+}
+
+// Run fires the due reminders right away and then every PollInterval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	const op = "reminders.Scheduler.Run"
+
+	log := s.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			fired, err := s.store.FireReminder(ctx, s.fire)
+			if err != nil {
+				log.Error("failed to fire reminder", sl.Err(err))
+
+				break
+			}
+			if !fired {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) fire(ctx context.Context, reminder models.DueReminder, notify Notify) models.ReminderResult {
+	log := s.log.With(
+		slog.Int64("reminder_id", reminder.ID),
+		slog.String("channel", reminder.Channel),
+	)
+
+	channel, ok := s.channels[reminder.Channel]
+	if !ok {
+		log.Error("reminder has an unknown channel")
+
+		return models.ReminderResult{Err: fmt.Errorf("%w: unknown channel %q", ErrUndeliverable, reminder.Channel)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.DeliveryTimeout)
+	defer cancel()
+
+	err := channel.Send(ctx, reminder, notify)
+	if err == nil {
+		log.Info("reminder sent")
+
+		return models.ReminderResult{}
+	}
+
+	attempts := reminder.Attempts + 1
+	if errors.Is(err, ErrUndeliverable) || attempts >= s.cfg.MaxAttempts {
+		log.Warn("reminder given up", slog.Int("attempts", attempts), sl.Err(err))
+
+		return models.ReminderResult{Err: err}
+	}
+
+	retryAt := time.Now().Add(s.delay(attempts))
+	log.Info("reminder delivery failed", slog.Time("retry_at", retryAt), sl.Err(err))
+
+	return models.ReminderResult{Err: err, RetryAt: retryAt}
+}
+
+// delay is the wait before the next delivery after attempts failed ones.
+func (s *Scheduler) delay(attempts int) time.Duration {
+	delay := s.cfg.BaseDelay
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= s.cfg.MaxDelay {
+			return s.cfg.MaxDelay
+		}
+	}
+
+	return min(delay, s.cfg.MaxDelay)
+}
@@ -0,0 +1,73 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+	"todo/internal/models"
+)
+
+func TestSchedulerDelay(t *testing.T) {
+	s := NewScheduler(nil, nil, nil, Config{BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 3, want: 4 * time.Minute},
+		{attempts: 7, want: time.Hour},
+		{attempts: 1000, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := s.delay(tt.attempts); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+type channelFunc func(ctx context.Context, reminder models.DueReminder, notify Notify) error
+
+func (f channelFunc) Send(ctx context.Context, reminder models.DueReminder, notify Notify) error {
+	return f(ctx, reminder, notify)
+}
+
+func TestSchedulerFire(t *testing.T) {
+	errDown := errors.New("server is down")
+
+	tests := []struct {
+		name      string
+		channel   string
+		attempts  int
+		err       error
+		wantErr   bool
+		wantRetry bool
+	}{
+		{name: "sent", channel: "test"},
+		{name: "failed", channel: "test", err: errDown, wantErr: true, wantRetry: true},
+		{name: "failed for the last time", channel: "test", attempts: 2, err: errDown, wantErr: true},
+		{name: "undeliverable", channel: "test", err: ErrUndeliverable, wantErr: true},
+		{name: "unknown channel", channel: "pigeon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, map[string]Channel{
+				"test": channelFunc(func(context.Context, models.DueReminder, Notify) error { return tt.err }),
+			}, Config{DeliveryTimeout: time.Second, MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+
+			result := s.fire(context.Background(), models.DueReminder{
+				Reminder: models.Reminder{Channel: tt.channel, Attempts: tt.attempts},
+			}, nil)
+
+			if (result.Err != nil) != tt.wantErr || result.RetryAt.IsZero() == tt.wantRetry {
+				t.Errorf("fire() = %+v, want error %v and retry %v", result, tt.wantErr, tt.wantRetry)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"syscall"
+	"time"
+	"todo/internal/models"
+)
+
+// ErrForbiddenWebhook is returned for webhook urls that aren't public http(s) urls,
+// so that users can't make the server call into the network it runs in.
+var ErrForbiddenWebhook = errors.New("webhook url is not allowed")
+
+// reservedPrefixes aren't public either, though netip has no check for them.
+var reservedPrefixes = []netip.Prefix{
+	// "this network" (RFC 791), 0.0.0.0 reaches the local host on Linux
+	netip.MustParsePrefix("0.0.0.0/8"),
+	// carrier-grade NAT (RFC 6598)
+	netip.MustParsePrefix("100.64.0.0/10"),
+	// IETF protocol assignments (RFC 6890)
+	netip.MustParsePrefix("192.0.0.0/24"),
+	// benchmarking (RFC 2544)
+	netip.MustParsePrefix("198.18.0.0/15"),
+	// reserved for future use and the limited broadcast address (RFC 1112, RFC 919)
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// NAT64 (RFC 6052), translated to an IPv4 address that may be private
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Webhook posts reminders as JSON to the webhook_url of the reminder.
+// Any status but 2xx is a failed delivery, redirects aren't followed.
+type Webhook struct {
+	client    *http.Client
+	httpsOnly bool
+}
+
+// NewWebhook returns a channel that only connects to public addresses. The address
+// is checked when the connection is made, after DNS resolution, so a host that
+// resolves to a private address later on is refused all the same.
+func NewWebhook(timeout time.Duration, httpsOnly bool) *Webhook {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || forbiddenAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenWebhook, address)
+			}
+
+			return nil
+		},
+	}
+
+	return &Webhook{
+		client: &http.Client{
+			Timeout: timeout,
+			// without Proxy, as the proxy would be checked and not the host
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		httpsOnly: httpsOnly,
+	}
+}
+
+// CheckURL returns ErrForbiddenWebhook unless rawURL is an http(s) url, only https
+// when so configured, whose host resolves to public addresses only.
+func (c *Webhook) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("%w: not an absolute url", ErrForbiddenWebhook)
+	}
+
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && !c.httpsOnly:
+	default:
+		return fmt.Errorf("%w: scheme %q", ErrForbiddenWebhook, u.Scheme)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrForbiddenWebhook, err)
+	}
+
+	for _, addr := range addrs {
+		if forbiddenAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenWebhook, u.Hostname(), addr)
+		}
+	}
+
+	return nil
+}
+
+// forbiddenAddr reports whether addr is a loopback, private, link-local, unspecified
+// or otherwise non-public address.
+func forbiddenAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() ||
+		slices.ContainsFunc(reservedPrefixes, func(prefix netip.Prefix) bool {
+			return prefix.Contains(addr)
+		})
+}
+
+type webhookPayload struct {
+	ReminderID int64      `json:"reminder_id"`
+	NoteID     int64      `json:"note_id"`
+	Title      string     `json:"title"`
+	DueAt      *time.Time `json:"due_at,omitempty"`
+	FireAt     *time.Time `json:"fire_at,omitempty"`
+}
+
+func (c *Webhook) Send(ctx context.Context, reminder models.DueReminder, _ Notify) error {
+	const op = "reminders.Webhook.Send"
+
+	u, err := url.Parse(reminder.WebhookURL)
+	if err != nil || (u.Scheme != "https" && (u.Scheme != "http" || c.httpsOnly)) {
+		return fmt.Errorf("%s: %w: %w", op, ErrUndeliverable, ErrForbiddenWebhook)
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		ReminderID: reminder.ID,
+		NoteID:     reminder.NoteID,
+		Title:      reminder.NoteTitle,
+		DueAt:      reminder.NoteDueAt,
+		FireAt:     reminder.FireAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reminder.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w: %w", op, ErrUndeliverable, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(req)
+	if errors.Is(err, ErrForbiddenWebhook) {
+		return fmt.Errorf("%s: %w: %w", op, ErrUndeliverable, err)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s: webhook answered %s", op, res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"todo/pkg/auth"
+)
+
+type Store interface {
+	RevokeAccessToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userID int) (time.Time, error)
+	IsTokenRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error)
+}
+
+// Checker answers whether an access token is revoked. Revocations are kept in memory
+// until the token expires, so tokens revoked through this instance are rejected without
+// a database round trip. Tokens found to be valid are cached for validTTL only,
+// which bounds how long a revocation made by another instance can go unnoticed.
+type Checker struct {
+	store    Store
+	validTTL time.Duration
+
+	mu         sync.RWMutex
+	revoked    map[string]time.Time
+	valid      map[string]time.Time
+	validAfter map[int]time.Time
+	lastSweep  time.Time
+}
+
+func NewChecker(store Store, validTTL time.Duration) *Checker {
+	return &Checker{
+		store:      store,
+		validTTL:   validTTL,
+		revoked:    make(map[string]time.Time),
+		valid:      make(map[string]time.Time),
+		validAfter: make(map[int]time.Time),
+		lastSweep:  time.Now(),
+	}
+}
+
+func (c *Checker) IsRevoked(ctx context.Context, claims auth.Claims) (bool, error) {
+	const op = "revocation.IsRevoked"
+	now := time.Now()
+
+	c.mu.RLock()
+	_, revoked := c.revoked[claims.TokenID]
+	validUntil, valid := c.valid[claims.TokenID]
+	validAfter, hasValidAfter := c.validAfter[claims.UserID]
+	c.mu.RUnlock()
+
+	if revoked || (hasValidAfter && claims.IssuedAt.Before(validAfter)) {
+		return true, nil
+	}
+	if valid && now.Before(validUntil) {
+		return false, nil
+	}
+
+	revoked, err := c.store.IsTokenRevoked(ctx, claims.TokenID, claims.UserID, claims.IssuedAt)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if revoked {
+		c.revoked[claims.TokenID] = claims.ExpiresAt
+		delete(c.valid, claims.TokenID)
+	} else {
+		c.valid[claims.TokenID] = now.Add(c.validTTL)
+	}
+
+	c.sweep(now)
+
+	return revoked, nil
+}
+
+func (c *Checker) RevokeToken(ctx context.Context, claims auth.Claims) error {
+	const op = "revocation.RevokeToken"
+
+	if err := c.store.RevokeAccessToken(ctx, claims.TokenID, claims.UserID, claims.ExpiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.revoked[claims.TokenID] = claims.ExpiresAt
+	delete(c.valid, claims.TokenID)
+
+	return nil
+}
+
+// RevokeUserTokens revokes every token issued to the user so far.
+func (c *Checker) RevokeUserTokens(ctx context.Context, userID int) error {
+	const op = "revocation.RevokeUserTokens"
+
+	validAfter, err := c.store.RevokeUserTokens(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.validAfter[userID] = validAfter
+	// cached valid tokens of the user can't be told apart by user id, so drop them all
+	c.valid = make(map[string]time.Time)
+
+	return nil
+}
+
+// sweep drops expired entries at most once a minute. c.mu must be held.
+func (c *Checker) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < time.Minute {
+		return
+	}
+
+	for tokenID, expiresAt := range c.revoked {
+		if now.After(expiresAt) {
+			delete(c.revoked, tokenID)
+		}
+	}
+
+	for tokenID, validUntil := range c.valid {
+		if now.After(validUntil) {
+			delete(c.valid, tokenID)
+		}
+	}
+
+	c.lastSweep = now
+}
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS password_hash text NOT NULL DEFAULT '';
+
+ALTER TABLE users
+    ALTER COLUMN password_hash DROP DEFAULT;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS refresh_sessions
+(
+    id         int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id  uuid        NOT NULL DEFAULT gen_random_uuid(),
+    token_hash text        NOT NULL UNIQUE,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS refresh_sessions_family_id_idx ON refresh_sessions (family_id);
+CREATE INDEX IF NOT EXISTS refresh_sessions_user_id_idx ON refresh_sessions (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS refresh_sessions;
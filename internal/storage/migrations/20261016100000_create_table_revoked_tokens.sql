@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS revoked_tokens
+(
+    jti        text        PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS tokens_valid_after timestamptz;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN IF EXISTS tokens_valid_after;
+
+DROP TABLE IF EXISTS revoked_tokens;
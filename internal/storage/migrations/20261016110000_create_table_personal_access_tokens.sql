@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS personal_access_tokens
+(
+    id           int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id      int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         text        NOT NULL,
+    token_hash   text        NOT NULL UNIQUE,
+    scopes       text[]      NOT NULL,
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz,
+    created_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS personal_access_tokens_user_id_idx ON personal_access_tokens (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS personal_access_tokens;
@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret    text,
+    ADD COLUMN IF NOT EXISTS totp_enabled   boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS totp_last_step bigint;
+
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    id         int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash  text        NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS totp_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,28 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email             text,
+    ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (lower(email));
+
+CREATE TABLE IF NOT EXISTS email_tokens
+(
+    id         int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose    text        NOT NULL,
+    token_hash text        NOT NULL UNIQUE,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS email_tokens_user_id_purpose_idx ON email_tokens (user_id, purpose);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_tokens;
+
+DROP INDEX IF EXISTS users_email_key;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email_verified_at,
+    DROP COLUMN IF EXISTS email;
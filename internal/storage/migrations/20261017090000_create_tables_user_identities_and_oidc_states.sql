@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_identities
+(
+    id         int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer     text        NOT NULL,
+    subject    text        NOT NULL,
+    email      text,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON user_identities (user_id);
+
+CREATE TABLE IF NOT EXISTS oidc_states
+(
+    state         text        PRIMARY KEY,
+    code_verifier text        NOT NULL,
+    nonce         text        NOT NULL,
+    expires_at    timestamptz NOT NULL,
+    created_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS oidc_states;
+DROP TABLE IF EXISTS user_identities;
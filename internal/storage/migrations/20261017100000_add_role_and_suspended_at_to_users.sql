@@ -0,0 +1,20 @@
+-- +goose Up
+-- the first admin is appointed by hand:
+-- UPDATE users SET role = 'admin' WHERE username = '...';
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role         text NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    ADD COLUMN IF NOT EXISTS suspended_at timestamptz;
+
+-- notes go away together with their owner
+ALTER TABLE notes
+    DROP CONSTRAINT IF EXISTS notes_user_id_fkey,
+    ADD CONSTRAINT notes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE notes
+    DROP CONSTRAINT IF EXISTS notes_user_id_fkey,
+    ADD CONSTRAINT notes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS suspended_at,
+    DROP COLUMN IF EXISTS role;
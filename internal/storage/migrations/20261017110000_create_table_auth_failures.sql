@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS auth_failures
+(
+    kind            text        NOT NULL,
+    subject         text        NOT NULL,
+    failures        int         NOT NULL,
+    locked_until    timestamptz,
+    last_failure_at timestamptz NOT NULL,
+    PRIMARY KEY (kind, subject)
+);
+
+CREATE INDEX IF NOT EXISTS auth_failures_locked_until_idx ON auth_failures (locked_until);
+
+-- +goose Down
+DROP TABLE IF EXISTS auth_failures;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS status       text        NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'in_progress', 'done')),
+    ADD COLUMN IF NOT EXISTS completed_at timestamptz,
+    ADD COLUMN IF NOT EXISTS due_at       timestamptz,
+    ADD COLUMN IF NOT EXISTS priority     smallint    NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 3),
+    ADD CONSTRAINT notes_completed_at_check CHECK ((status = 'done') = (completed_at IS NOT NULL));
+
+CREATE INDEX IF NOT EXISTS notes_user_id_status_due_at_idx ON notes (user_id, status, due_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS notes_user_id_status_due_at_idx;
+
+ALTER TABLE notes
+    DROP CONSTRAINT IF EXISTS notes_completed_at_check,
+    DROP COLUMN IF EXISTS priority,
+    DROP COLUMN IF EXISTS due_at,
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS status;
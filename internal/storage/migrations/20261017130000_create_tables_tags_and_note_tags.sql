@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tags
+(
+    id         int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT tags_user_id_name_key UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS note_tags
+(
+    note_id int NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    tag_id  int NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS note_tags_tag_id_idx ON note_tags (tag_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS note_tags;
+DROP TABLE IF EXISTS tags;
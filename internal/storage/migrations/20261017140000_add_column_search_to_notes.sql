@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', content), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS notes_search_idx ON notes USING GIN (search);
+
+-- +goose Down
+DROP INDEX IF EXISTS notes_search_idx;
+
+ALTER TABLE notes
+    DROP COLUMN IF EXISTS search;
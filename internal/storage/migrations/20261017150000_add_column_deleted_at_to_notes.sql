@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS notes_deleted_at_idx ON notes (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS notes_deleted_at_idx;
+
+ALTER TABLE notes
+    DROP COLUMN IF EXISTS deleted_at;
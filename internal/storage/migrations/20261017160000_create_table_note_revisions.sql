@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS note_revisions
+(
+    id         int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    note_id    int         NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    revision   int         NOT NULL,
+    title      text        NOT NULL,
+    content    text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (note_id, revision)
+);
+
+-- the current state of existing notes becomes their first revision
+INSERT INTO note_revisions (note_id, revision, title, content, created_at)
+SELECT id, 1, title, content, updated_at
+FROM notes;
+
+-- +goose Down
+DROP TABLE IF EXISTS note_revisions;
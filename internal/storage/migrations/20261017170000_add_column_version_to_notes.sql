@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+
+DROP TRIGGER IF EXISTS notes_before_update_version_trg ON notes;
+DROP FUNCTION IF EXISTS tg_increment_version;
+
+-- +goose StatementBegin
+CREATE FUNCTION tg_increment_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER notes_before_update_version_trg BEFORE UPDATE ON notes
+    FOR EACH ROW EXECUTE PROCEDURE tg_increment_version();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS notes_before_update_version_trg ON notes;
+DROP FUNCTION IF EXISTS tg_increment_version;
+
+ALTER TABLE notes
+    DROP COLUMN IF EXISTS version;
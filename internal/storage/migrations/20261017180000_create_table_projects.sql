@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS projects
+(
+    id         int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id  int         REFERENCES projects(id) ON DELETE CASCADE,
+    name       text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS projects_user_id_idx ON projects (user_id);
+CREATE INDEX IF NOT EXISTS projects_parent_id_idx ON projects (parent_id);
+
+CREATE TRIGGER projects_before_update_trg BEFORE UPDATE ON projects
+    FOR EACH ROW EXECUTE PROCEDURE tg_set_updated_at();
+
+-- notes without a project are in the inbox
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS project_id int REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS notes_project_id_idx ON notes (project_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS notes_project_id_idx;
+
+ALTER TABLE notes
+    DROP COLUMN IF EXISTS project_id;
+
+DROP TABLE IF EXISTS projects;
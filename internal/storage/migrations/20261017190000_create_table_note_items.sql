@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS note_items
+(
+    id           int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    note_id      int         NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    position     int         NOT NULL,
+    text         text        NOT NULL,
+    done         boolean     NOT NULL DEFAULT false,
+    completed_at timestamptz,
+    created_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT note_items_completed_at_check CHECK (done = (completed_at IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS note_items_note_id_position_idx ON note_items (note_id, position);
+
+CREATE TRIGGER note_items_before_update_trg BEFORE UPDATE ON note_items
+    FOR EACH ROW EXECUTE PROCEDURE tg_set_updated_at();
+
+-- +goose Down
+DROP TABLE IF EXISTS note_items;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- recurrence_start is the start of the series the note belongs to, it is kept
+-- while the rule stays the same so that COUNT and UNTIL hold across occurrences.
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS rrule                  text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS timezone               text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS recurrence_start       timestamptz,
+    ADD COLUMN IF NOT EXISTS previous_occurrence_id int REFERENCES notes(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS notes_previous_occurrence_id_idx ON notes (previous_occurrence_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS notes_previous_occurrence_id_idx;
+
+ALTER TABLE notes
+    DROP COLUMN IF EXISTS previous_occurrence_id,
+    DROP COLUMN IF EXISTS recurrence_start,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS rrule;
@@ -0,0 +1,63 @@
+-- +goose Up
+-- a reminder is due at remind_at, or due_offset seconds from the due_at of its note.
+-- fire_at is when it is due, it follows due_at until the reminder is sent.
+CREATE TABLE IF NOT EXISTS reminders
+(
+    id          int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    note_id     int         NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    remind_at   timestamptz,
+    due_offset  int,
+    channel     text        NOT NULL,
+    webhook_url text        NOT NULL DEFAULT '',
+    fire_at     timestamptz,
+    attempts    int         NOT NULL DEFAULT 0,
+    retry_at    timestamptz,
+    last_error  text        NOT NULL DEFAULT '',
+    sent_at     timestamptz,
+    failed_at   timestamptz,
+    created_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT reminders_time_check CHECK ((remind_at IS NULL) <> (due_offset IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS reminders_note_id_idx ON reminders (note_id);
+CREATE INDEX IF NOT EXISTS reminders_pending_idx ON reminders (COALESCE(retry_at, fire_at))
+    WHERE sent_at IS NULL AND failed_at IS NULL;
+
+DROP TRIGGER IF EXISTS notes_after_update_due_at_trg ON notes;
+DROP FUNCTION IF EXISTS tg_reschedule_reminders;
+
+-- +goose StatementBegin
+CREATE FUNCTION tg_reschedule_reminders() RETURNS trigger AS $$
+BEGIN
+    UPDATE reminders
+    SET fire_at = NEW.due_at + make_interval(secs => due_offset)
+    WHERE note_id = NEW.id AND due_offset IS NOT NULL AND sent_at IS NULL AND failed_at IS NULL;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER notes_after_update_due_at_trg AFTER UPDATE OF due_at ON notes
+    FOR EACH ROW WHEN (OLD.due_at IS DISTINCT FROM NEW.due_at) EXECUTE PROCEDURE tg_reschedule_reminders();
+
+-- the in-app inbox, fired reminders of the inapp channel end up here
+CREATE TABLE IF NOT EXISTS notifications
+(
+    id         int         GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    int         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    note_id    int         REFERENCES notes(id) ON DELETE SET NULL,
+    title      text        NOT NULL,
+    body       text        NOT NULL DEFAULT '',
+    read_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_id_idx ON notifications (user_id, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS notifications;
+
+DROP TRIGGER IF EXISTS notes_after_update_due_at_trg ON notes;
+DROP FUNCTION IF EXISTS tg_reschedule_reminders;
+
+DROP TABLE IF EXISTS reminders;
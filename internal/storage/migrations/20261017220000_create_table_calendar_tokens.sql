@@ -0,0 +1,12 @@
+-- +goose Up
+-- a user has at most one calendar feed token, calendar apps send it in the feed url
+CREATE TABLE IF NOT EXISTS calendar_tokens
+(
+    user_id      int         PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash   text        NOT NULL UNIQUE,
+    last_used_at timestamptz,
+    created_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS calendar_tokens;
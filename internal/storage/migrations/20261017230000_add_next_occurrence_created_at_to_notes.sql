@@ -0,0 +1,27 @@
+-- +goose Up
+-- next_occurrence_created_at marks a done recurring note whose next occurrence was
+-- created, so that it isn't created again once that occurrence is purged.
+ALTER TABLE notes
+    ADD COLUMN IF NOT EXISTS next_occurrence_created_at timestamptz;
+
+UPDATE notes
+SET next_occurrence_created_at = n.created_at
+FROM notes n
+WHERE n.previous_occurrence_id = notes.id;
+
+-- setting the mark isn't a change of the note, it keeps its version
+DROP TRIGGER IF EXISTS notes_before_update_version_trg ON notes;
+
+CREATE TRIGGER notes_before_update_version_trg BEFORE UPDATE ON notes
+    FOR EACH ROW
+    WHEN (OLD.next_occurrence_created_at IS NOT DISTINCT FROM NEW.next_occurrence_created_at)
+    EXECUTE PROCEDURE tg_increment_version();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS notes_before_update_version_trg ON notes;
+
+CREATE TRIGGER notes_before_update_version_trg BEFORE UPDATE ON notes
+    FOR EACH ROW EXECUTE PROCEDURE tg_increment_version();
+
+ALTER TABLE notes
+    DROP COLUMN IF EXISTS next_occurrence_created_at;
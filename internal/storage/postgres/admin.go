@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+func (s *Storage) GetUsers(ctx context.Context, limit, offset int) ([]models.User, error) {
+	const op = "storage.postgres.GetUsers"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resUsers := make([]models.User, 0)
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT `+userColumns+`
+		FROM users
+		ORDER BY id
+		LIMIT $1 OFFSET $2`,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resUsers = append(resUsers, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resUsers, nil
+}
+
+// SuspendUser suspends the user and revokes their refresh sessions,
+// so a suspended user has to log in again after reactivation.
+func (s *Storage) SuspendUser(ctx context.Context, userID int) error {
+	const op = "storage.postgres.SuspendUser"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(
+		ctx,
+		`UPDATE users
+		SET suspended_at = COALESCE(suspended_at, CURRENT_TIMESTAMP)
+		WHERE id = $1`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE refresh_sessions
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ReactivateUser(ctx context.Context, userID int) error {
+	const op = "storage.postgres.ReactivateUser"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tag, err := s.pool.Exec(
+		ctx,
+		`UPDATE users
+		SET suspended_at = NULL
+		WHERE id = $1`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateUserRole(ctx context.Context, userID int, role string) error {
+	const op = "storage.postgres.UpdateUserRole"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tag, err := s.pool.Exec(
+		ctx,
+		`UPDATE users
+		SET role = $2
+		WHERE id = $1`,
+		userID,
+		role,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user together with everything they own.
+func (s *Storage) DeleteUser(ctx context.Context, userID int) error {
+	const op = "storage.postgres.DeleteUser"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	err := s.pool.QueryRow(
+		ctx,
+		`DELETE FROM users
+		WHERE id = $1
+		RETURNING id`,
+		userID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+const authLockoutColumns = `kind, subject, failures, locked_until, last_failure_at`
+
+func scanAuthLockout(row pgx.Row) (models.AuthLockout, error) {
+	var lockout models.AuthLockout
+
+	err := row.Scan(
+		&lockout.Kind,
+		&lockout.Subject,
+		&lockout.Failures,
+		&lockout.LockedUntil,
+		&lockout.LastFailureAt,
+	)
+
+	return lockout, err
+}
+
+func (s *Storage) queryAuthLockouts(ctx context.Context, sql string, args ...any) ([]models.AuthLockout, error) {
+	resLockouts := make([]models.AuthLockout, 0)
+
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lockout, err := scanAuthLockout(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		resLockouts = append(resLockouts, lockout)
+	}
+
+	return resLockouts, rows.Err()
+}
+
+// GetActiveAuthLockouts returns the lockouts in force for the (kinds[i], subjects[i]) keys.
+func (s *Storage) GetActiveAuthLockouts(ctx context.Context, kinds, subjects []string) ([]models.AuthLockout, error) {
+	const op = "storage.postgres.GetActiveAuthLockouts"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	lockouts, err := s.queryAuthLockouts(
+		ctx,
+		`SELECT `+authLockoutColumns+`
+		FROM auth_failures
+		WHERE (kind, subject) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+			AND locked_until > CURRENT_TIMESTAMP`,
+		kinds,
+		subjects,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lockouts, nil
+}
+
+func (s *Storage) GetAuthLockouts(ctx context.Context) ([]models.AuthLockout, error) {
+	const op = "storage.postgres.GetAuthLockouts"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	lockouts, err := s.queryAuthLockouts(
+		ctx,
+		`SELECT `+authLockoutColumns+`
+		FROM auth_failures
+		WHERE locked_until > CURRENT_TIMESTAMP
+		ORDER BY locked_until DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lockouts, nil
+}
+
+// IncrementAuthFailures records a failed attempt and returns the number of failures
+// in a row. The count starts over if the previous failure was before resetBefore.
+func (s *Storage) IncrementAuthFailures(ctx context.Context, kind, subject string, resetBefore time.Time) (int, error) {
+	const op = "storage.postgres.IncrementAuthFailures"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var failures int
+
+	if err := s.pool.QueryRow(
+		ctx,
+		`INSERT INTO auth_failures (kind, subject, failures, last_failure_at)
+		VALUES ($1, $2, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT (kind, subject) DO UPDATE
+		SET failures = CASE
+				WHEN auth_failures.last_failure_at < $3 THEN 1
+				ELSE auth_failures.failures + 1
+			END,
+			last_failure_at = CURRENT_TIMESTAMP
+		RETURNING failures`,
+		kind,
+		subject,
+		resetBefore,
+	).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return failures, nil
+}
+
+func (s *Storage) LockAuthSubject(ctx context.Context, kind, subject string, lockedUntil time.Time) error {
+	const op = "storage.postgres.LockAuthSubject"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	if _, err := s.pool.Exec(
+		ctx,
+		`UPDATE auth_failures
+		SET locked_until = $3
+		WHERE kind = $1 AND subject = $2`,
+		kind,
+		subject,
+		lockedUntil,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteAuthFailures(ctx context.Context, kind, subject string) (models.AuthLockout, error) {
+	const op = "storage.postgres.DeleteAuthFailures"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	lockout, err := scanAuthLockout(s.pool.QueryRow(
+		ctx,
+		`DELETE FROM auth_failures
+		WHERE kind = $1 AND subject = $2
+		RETURNING `+authLockoutColumns,
+		kind,
+		subject,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.AuthLockout{}, fmt.Errorf("%s: %w", op, storage.ErrNoAuthLockout)
+	}
+	if err != nil {
+		return models.AuthLockout{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lockout, nil
+}
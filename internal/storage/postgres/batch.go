@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// BatchNotes runs the operations in one transaction and returns their results in order.
+// Every operation runs in a savepoint: with bestEffort a failed one is rolled back alone
+// and the rest is committed, without it the first failure rolls back all of them and
+// the others get storage.ErrBatchAborted. The error is only set when the transaction
+// itself fails.
+func (s *Storage) BatchNotes(ctx context.Context, userID int, ops []models.NoteOperation, bestEffort bool) ([]models.NoteOperationResult, error) {
+	const op = "storage.postgres.BatchNotes"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	results := make([]models.NoteOperationResult, len(ops))
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i, noteOp := range ops {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		results[i], err = s.runNoteOperation(ctx, savepoint, userID, noteOp)
+		if err == nil {
+			err = savepoint.Commit(ctx)
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, fmt.Errorf("%s: %w", op, ctxErr)
+			}
+			if err := savepoint.Rollback(ctx); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			results[i] = models.NoteOperationResult{Err: err}
+
+			if !bestEffort {
+				return abortBatch(results, i), nil
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+func (s *Storage) runNoteOperation(ctx context.Context, tx pgx.Tx, userID int, noteOp models.NoteOperation) (models.NoteOperationResult, error) {
+	switch noteOp.Op {
+	case models.NoteOpCreate:
+		id, version, err := s.insertNote(ctx, tx, userID, *noteOp.Note)
+
+		return models.NoteOperationResult{ID: id, Version: version}, err
+	case models.NoteOpUpdate:
+		version, err := s.updateNote(ctx, tx, noteOp.NoteID, userID, *noteOp.Note, noteOp.Version)
+
+		return models.NoteOperationResult{ID: int64(noteOp.NoteID), Version: version}, err
+	case models.NoteOpDelete:
+		err := deleteNote(ctx, tx, noteOp.NoteID, userID, noteOp.Version)
+
+		return models.NoteOperationResult{ID: int64(noteOp.NoteID)}, err
+	case models.NoteOpComplete:
+		note, err := s.completeNote(ctx, tx, noteOp.NoteID, userID, noteOp.Version, noteOp.Items)
+
+		return models.NoteOperationResult{ID: note.ID, Version: note.Version}, err
+	default:
+		return models.NoteOperationResult{}, errors.New("unknown note operation " + noteOp.Op)
+	}
+}
+
+// abortBatch marks every result but the failed one as rolled back.
+func abortBatch(results []models.NoteOperationResult, failed int) []models.NoteOperationResult {
+	for i := range results {
+		if i != failed {
+			results[i] = models.NoteOperationResult{Err: storage.ErrBatchAborted}
+		}
+	}
+
+	return results
+}
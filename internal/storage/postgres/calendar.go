@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// maxCalendarNotes bounds the calendar feed, the notes due first are left out.
+const maxCalendarNotes = 5000
+
+// SaveCalendarToken sets the calendar feed token of the user, replacing the previous one.
+func (s *Storage) SaveCalendarToken(ctx context.Context, userID int, tokenHash string) error {
+	const op = "storage.postgres.SaveCalendarToken"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	if _, err := s.pool.Exec(
+		ctx,
+		`INSERT INTO calendar_tokens (user_id, token_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash,
+			last_used_at = NULL,
+			created_at = CURRENT_TIMESTAMP`,
+		userID,
+		tokenHash,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteCalendarToken(ctx context.Context, userID int) error {
+	const op = "storage.postgres.DeleteCalendarToken"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tag, err := s.pool.Exec(
+		ctx,
+		`DELETE FROM calendar_tokens
+		WHERE user_id = $1`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrNoCalendarToken)
+	}
+
+	return nil
+}
+
+// UseCalendarToken checks that tokenHash is the calendar token of the user,
+// who must not be suspended, and records when it was last used.
+func (s *Storage) UseCalendarToken(ctx context.Context, userID int, tokenHash string) error {
+	const op = "storage.postgres.UseCalendarToken"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	err := s.pool.QueryRow(
+		ctx,
+		`UPDATE calendar_tokens ct
+		SET last_used_at = CURRENT_TIMESTAMP
+		FROM users u
+		WHERE ct.user_id = $1 AND ct.token_hash = $2 AND u.id = ct.user_id AND u.suspended_at IS NULL
+		RETURNING ct.user_id`,
+		userID,
+		tokenHash,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, storage.ErrNoCalendarToken)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetCalendarNotes returns the user's notes that have a due_at, the ones due last first.
+func (s *Storage) GetCalendarNotes(ctx context.Context, userID int) ([]models.Note, error) {
+	const op = "storage.postgres.GetCalendarNotes"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resNotes := make([]models.Note, 0)
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT `+noteColumns+`
+		FROM notes
+		WHERE user_id = $1 AND deleted_at IS NULL AND due_at IS NOT NULL
+		ORDER BY due_at DESC, id DESC
+		LIMIT $2`,
+		userID,
+		maxCalendarNotes,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resNotes = append(resNotes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resNotes, nil
+}
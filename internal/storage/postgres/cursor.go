@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// noteCursor points right after the last note of a page: it holds the values
+// of the sort keys of that note. Clients get it as an opaque string
+// and must not build it themselves.
+type noteCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+func encodeNoteCursor(keys []models.SortKey, last models.Note) string {
+	c := noteCursor{Sort: sortString(keys), Values: make([]string, 0, len(keys))}
+
+	for _, k := range keys {
+		c.Values = append(c.Values, noteSortFields[k.Field].value(last))
+	}
+
+	// marshaling a string and a slice of strings can't fail
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeNoteCursor returns the sort key values of the cursor, which must
+// have been issued for the same keys.
+func decodeNoteCursor(cursor string, keys []models.SortKey) ([]string, bool) {
+	var c noteCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, false
+	}
+
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, false
+	}
+
+	if c.Sort != sortString(keys) || len(c.Values) != len(keys) {
+		return nil, false
+	}
+
+	return c.Values, true
+}
+
+// cursorErr turns the error of a cursor value that doesn't fit the type of its key,
+// which only a cursor made up by the client has, into storage.ErrInvalidCursor.
+func cursorErr(err error, after []string) error {
+	var pgErr *pgconn.PgError
+
+	if after != nil && errors.As(err, &pgErr) && pgerrcode.IsDataException(pgErr.Code) {
+		return storage.ErrInvalidCursor
+	}
+
+	return err
+}
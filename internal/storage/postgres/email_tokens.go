@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"todo/internal/storage"
+)
+
+const (
+	emailTokenPurposeVerification  = "email_verification"
+	emailTokenPurposePasswordReset = "password_reset"
+)
+
+func (s *Storage) SaveEmailVerificationToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	const op = "storage.postgres.SaveEmailVerificationToken"
+
+	if err := s.saveEmailToken(ctx, userID, emailTokenPurposeVerification, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SavePasswordResetToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	const op = "storage.postgres.SavePasswordResetToken"
+
+	if err := s.saveEmailToken(ctx, userID, emailTokenPurposePasswordReset, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// VerifyEmail uses the verification token and marks the user's email as verified.
+func (s *Storage) VerifyEmail(ctx context.Context, tokenHash string) (int64, error) {
+	const op = "storage.postgres.VerifyEmail"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	userID, err := useEmailToken(ctx, tx, emailTokenPurposeVerification, tokenHash)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE users
+		SET email_verified_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		userID,
+	); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// ResetPassword uses the password reset token and replaces the user's password hash.
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int64, error) {
+	const op = "storage.postgres.ResetPassword"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	userID, err := useEmailToken(ctx, tx, emailTokenPurposePasswordReset, tokenHash)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE users
+		SET password_hash = $1
+		WHERE id = $2`,
+		passwordHash,
+		userID,
+	); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// saveEmailToken stores a new token and drops the user's unused tokens
+// with the same purpose, so only the latest link works.
+func (s *Storage) saveEmailToken(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM email_tokens
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`,
+		userID,
+		purpose,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO email_tokens (user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		userID,
+		purpose,
+		tokenHash,
+		expiresAt,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func useEmailToken(ctx context.Context, tx pgx.Tx, purpose, tokenHash string) (int64, error) {
+	var id, userID int64
+	var expiresAt time.Time
+
+	err := tx.QueryRow(
+		ctx,
+		`SELECT id, user_id, expires_at
+		FROM email_tokens
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL
+		FOR UPDATE`,
+		tokenHash,
+		purpose,
+	).Scan(&id, &userID, &expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, storage.ErrNoEmailToken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if time.Now().After(expiresAt) {
+		return 0, storage.ErrEmailTokenExpired
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE email_tokens
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		id,
+	); err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"slices"
+	"strings"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// touchNote bumps the version of the note, its item counts are part of it.
+func touchNote(ctx context.Context, tx pgx.Tx, noteID int) error {
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE notes
+		SET version = version
+		WHERE id = $1`,
+		noteID,
+	)
+
+	return err
+}
+
+// SaveNoteItem adds the item to the end of the note's checklist.
+func (s *Storage) SaveNoteItem(ctx context.Context, noteID, userID int, item models.NoteItemRequest) (int64, error) {
+	const op = "storage.postgres.SaveNoteItem"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockNote(ctx, tx, noteID, userID, 0); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`INSERT INTO note_items (note_id, position, text, done, completed_at)
+		SELECT $1, COALESCE(max(position) + 1, 0), $2, $3, CASE WHEN $3 THEN CURRENT_TIMESTAMP END
+		FROM note_items
+		WHERE note_id = $1
+		RETURNING id`,
+		noteID,
+		strings.TrimSpace(item.Text),
+		item.Done,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := touchNote(ctx, tx, noteID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetNoteItems(ctx context.Context, noteID, userID int) ([]models.NoteItem, error) {
+	const op = "storage.postgres.GetNoteItems"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resItems := make([]models.NoteItem, 0)
+	var exists bool
+
+	if err := s.pool.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1
+			FROM notes
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		)`,
+		noteID,
+		userID,
+	).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
+	}
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT id, position, text, done, completed_at, created_at, updated_at
+		FROM note_items
+		WHERE note_id = $1
+		ORDER BY position, id`,
+		noteID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.NoteItem
+
+		if err := rows.Scan(
+			&item.ID,
+			&item.Position,
+			&item.Text,
+			&item.Done,
+			&item.CompletedAt,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resItems = append(resItems, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resItems, nil
+}
+
+// UpdateNoteItem replaces the text of the item and checks or unchecks it.
+func (s *Storage) UpdateNoteItem(ctx context.Context, itemID, noteID, userID int, item models.NoteItemRequest) (int64, error) {
+	const op = "storage.postgres.UpdateNoteItem"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockNote(ctx, tx, noteID, userID, 0); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE note_items
+		SET text = $1,
+			done = $2,
+			completed_at = CASE WHEN $2 THEN COALESCE(completed_at, CURRENT_TIMESTAMP) END
+		WHERE id = $3 AND note_id = $4
+		RETURNING id`,
+		strings.TrimSpace(item.Text),
+		item.Done,
+		itemID,
+		noteID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoNoteItem)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := touchNote(ctx, tx, noteID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) DeleteNoteItem(ctx context.Context, itemID, noteID, userID int) (int64, error) {
+	const op = "storage.postgres.DeleteNoteItem"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockNote(ctx, tx, noteID, userID, 0); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(
+		ctx,
+		`DELETE FROM note_items
+		WHERE id = $1 AND note_id = $2
+		RETURNING id`,
+		itemID,
+		noteID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoNoteItem)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := touchNote(ctx, tx, noteID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// ReorderNoteItems puts the items of the note in the order of itemIDs,
+// which must list each of them exactly once.
+func (s *Storage) ReorderNoteItems(ctx context.Context, noteID, userID int, itemIDs []int64) error {
+	const op = "storage.postgres.ReorderNoteItems"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var currentIDs []int64
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockNote(ctx, tx, noteID, userID, 0); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`SELECT COALESCE(array_agg(id ORDER BY id), '{}')
+		FROM note_items
+		WHERE note_id = $1`,
+		noteID,
+	).Scan(&currentIDs); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !slices.Equal(slices.Sorted(slices.Values(itemIDs)), currentIDs) {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidItemsOrder)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE note_items i
+		SET position = o.ordinality - 1
+		FROM unnest($1::int[]) WITH ORDINALITY o(id, ordinality)
+		WHERE i.id = o.id AND i.note_id = $2`,
+		itemIDs,
+		noteID,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := touchNote(ctx, tx, noteID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// SaveTOTPSecret stores a secret that is not enabled until EnableTOTP is called.
+// It replaces a previously enrolled but unconfirmed secret.
+func (s *Storage) SaveTOTPSecret(ctx context.Context, userID int, secret string) error {
+	const op = "storage.postgres.SaveTOTPSecret"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tag, err := s.pool.Exec(
+		ctx,
+		`UPDATE users
+		SET totp_secret = $1, totp_last_step = NULL
+		WHERE id = $2 AND NOT totp_enabled`,
+		secret,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPAlreadyEnabled)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetTOTP(ctx context.Context, userID int) (models.TOTP, error) {
+	const op = "storage.postgres.GetTOTP"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var totp models.TOTP
+	var secret *string
+
+	err := s.pool.QueryRow(
+		ctx,
+		`SELECT totp_secret, totp_enabled, totp_last_step
+		FROM users
+		WHERE id = $1`,
+		userID,
+	).Scan(&secret, &totp.Enabled, &totp.LastStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.TOTP{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if err != nil {
+		return models.TOTP{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if secret == nil {
+		return models.TOTP{}, fmt.Errorf("%s: %w", op, storage.ErrTOTPNotEnrolled)
+	}
+
+	totp.Secret = *secret
+
+	return totp, nil
+}
+
+// EnableTOTP enables the enrolled secret, remembers step as used
+// and replaces the user's recovery codes.
+func (s *Storage) EnableTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	const op = "storage.postgres.EnableTOTP"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(
+		ctx,
+		`UPDATE users
+		SET totp_enabled = true, totp_last_step = $1
+		WHERE id = $2 AND totp_secret IS NOT NULL AND NOT totp_enabled`,
+		step,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPAlreadyEnabled)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM recovery_codes
+		WHERE user_id = $1`,
+		userID,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO recovery_codes (user_id, code_hash)
+		SELECT $1, unnest($2::text[])`,
+		userID,
+		recoveryCodeHashes,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep remembers step as used. It fails with storage.ErrTOTPStepUsed
+// if the same or a later step was already used, so every code works only once.
+func (s *Storage) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	const op = "storage.postgres.UseTOTPStep"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tag, err := s.pool.Exec(
+		ctx,
+		`UPDATE users
+		SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`,
+		step,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTOTPStepUsed)
+	}
+
+	return nil
+}
+
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	const op = "storage.postgres.UseRecoveryCode"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tag, err := s.pool.Exec(
+		ctx,
+		`UPDATE recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID,
+		codeHash,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrNoRecoveryCode)
+	}
+
+	return nil
+}
+
+func (s *Storage) DisableTOTP(ctx context.Context, userID int) error {
+	const op = "storage.postgres.DisableTOTP"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE users
+		SET totp_secret = NULL, totp_enabled = false, totp_last_step = NULL
+		WHERE id = $1`,
+		userID,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM recovery_codes
+		WHERE user_id = $1`,
+		userID,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
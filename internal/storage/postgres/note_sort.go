@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"todo/internal/models"
+)
+
+// noteSortField is a column notes can be ordered by. expr is never NULL, so the keyset
+// condition can compare it, and value renders a note's value as a cursor value of type cast.
+type noteSortField struct {
+	expr  string
+	cast  string
+	value func(note models.Note) string
+}
+
+var noteSortFields = map[string]noteSortField{
+	"created_at": {
+		expr:  "created_at",
+		cast:  "timestamptz",
+		value: func(note models.Note) string { return note.CreatedAt.Format(time.RFC3339Nano) },
+	},
+	"updated_at": {
+		expr:  "updated_at",
+		cast:  "timestamptz",
+		value: func(note models.Note) string { return note.UpdatedAt.Format(time.RFC3339Nano) },
+	},
+	"title": {
+		expr:  "title",
+		cast:  "text",
+		value: func(note models.Note) string { return note.Title },
+	},
+	"priority": {
+		expr:  "priority",
+		cast:  "smallint",
+		value: func(note models.Note) string { return strconv.Itoa(note.Priority) },
+	},
+	// notes without a due date come after all others
+	"due_at": {
+		expr: "COALESCE(due_at, 'infinity')",
+		cast: "timestamptz",
+		value: func(note models.Note) string {
+			if note.DueAt == nil {
+				return "infinity"
+			}
+
+			return note.DueAt.Format(time.RFC3339Nano)
+		},
+	},
+	"id": {
+		expr:  "id",
+		cast:  "bigint",
+		value: func(note models.Note) string { return strconv.FormatInt(note.ID, 10) },
+	},
+}
+
+// noteSortKeys returns the fields of sort with id appended, which makes the order total.
+func noteSortKeys(sort []models.SortKey) ([]models.SortKey, bool) {
+	keys := make([]models.SortKey, 0, len(sort)+1)
+	desc := false
+
+	for _, k := range sort {
+		if _, ok := noteSortFields[k.Field]; !ok || k.Field == "id" {
+			return nil, false
+		}
+
+		keys = append(keys, k)
+		desc = k.Desc
+	}
+
+	return append(keys, models.SortKey{Field: "id", Desc: desc}), true
+}
+
+func sortString(keys []models.SortKey) string {
+	fields := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		if k.Desc {
+			fields = append(fields, "-"+k.Field)
+		} else {
+			fields = append(fields, k.Field)
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// orderNotes orders q by keys and, with a cursor, keeps only the notes after it:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+func orderNotes(q *query, keys []models.SortKey, after []string) {
+	for _, k := range keys {
+		q.order(noteSortFields[k.Field].expr, k.Desc)
+	}
+
+	if after == nil {
+		return
+	}
+
+	q.whereAny(func(or *query) {
+		for i, k := range keys {
+			var alternative []string
+			var values []any
+
+			for j, prev := range keys[:i] {
+				field := noteSortFields[prev.Field]
+				alternative = append(alternative, field.expr+" = ?::"+field.cast)
+				values = append(values, after[j])
+			}
+
+			field := noteSortFields[k.Field]
+			comparison := " > "
+			if k.Desc {
+				comparison = " < "
+			}
+
+			alternative = append(alternative, field.expr+comparison+"?::"+field.cast)
+			values = append(values, after[i])
+
+			or.where("("+strings.Join(alternative, " AND ")+")", values...)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+func saveNotification(ctx context.Context, tx pgx.Tx, userID int64, noteID *int64, title, body string) error {
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO notifications (user_id, note_id, title, body)
+		VALUES ($1, $2, $3, $4)`,
+		userID,
+		noteID,
+		title,
+		body,
+	)
+
+	return err
+}
+
+// GetNotifications returns the user's notifications, the newest first, with unread only the unread ones.
+func (s *Storage) GetNotifications(ctx context.Context, userID int, unread bool, limit, offset int) ([]models.Notification, error) {
+	const op = "storage.postgres.GetNotifications"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resNotifications := make([]models.Notification, 0, limit)
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT id, note_id, title, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY id DESC
+		LIMIT $3
+		OFFSET $4`,
+		userID,
+		unread,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notification models.Notification
+
+		if err := rows.Scan(
+			&notification.ID,
+			&notification.NoteID,
+			&notification.Title,
+			&notification.Body,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resNotifications = append(resNotifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resNotifications, nil
+}
+
+// ReadNotification marks the notification read, reading it again keeps its read_at.
+func (s *Storage) ReadNotification(ctx context.Context, notificationID, userID int) (int64, error) {
+	const op = "storage.postgres.ReadNotification"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	err := s.pool.QueryRow(
+		ctx,
+		`UPDATE notifications
+		SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2
+		RETURNING id`,
+		notificationID,
+		userID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoNotification)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"strings"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+const maxUsernameAttempts = 10
+
+// identityLock serializes the first logins of one identity, so that two concurrent
+// callbacks can't both create a user for it.
+const identityLock = 2
+
+func (s *Storage) SaveOIDCState(ctx context.Context, state, codeVerifier, nonce string, expiresAt time.Time) error {
+	const op = "storage.postgres.SaveOIDCState"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	if _, err := s.pool.Exec(
+		ctx,
+		`INSERT INTO oidc_states (state, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		state,
+		codeVerifier,
+		nonce,
+		expiresAt,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseOIDCState deletes the login state and returns its code verifier and nonce,
+// so every state can finish only one login.
+func (s *Storage) UseOIDCState(ctx context.Context, state string) (string, string, error) {
+	const op = "storage.postgres.UseOIDCState"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var codeVerifier, nonce string
+	var expiresAt time.Time
+
+	err := s.pool.QueryRow(
+		ctx,
+		`DELETE FROM oidc_states
+		WHERE state = $1
+		RETURNING code_verifier, nonce, expires_at`,
+		state,
+	).Scan(&codeVerifier, &nonce, &expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", fmt.Errorf("%s: %w", op, storage.ErrNoOIDCState)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if time.Now().After(expiresAt) {
+		return "", "", fmt.Errorf("%s: %w", op, storage.ErrNoOIDCState)
+	}
+
+	return codeVerifier, nonce, nil
+}
+
+// LinkOIDCIdentity returns the user linked to the identity. An unknown identity is linked
+// to the user with the same email if both the provider and we have verified it,
+// otherwise a new user without a password is created for it.
+func (s *Storage) LinkOIDCIdentity(ctx context.Context, identity models.OIDCIdentity) (models.User, error) {
+	const op = "storage.postgres.LinkOIDCIdentity"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the second login waits for the first one to commit and then finds its link
+	if _, err := tx.Exec(
+		ctx,
+		`SELECT pg_advisory_xact_lock($1, hashtext($2 || ' ' || $3))`,
+		identityLock,
+		identity.Issuer,
+		identity.Subject,
+	); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := scanUser(tx.QueryRow(
+		ctx,
+		`SELECT `+userColumns+`
+		FROM users
+		WHERE id = (
+			SELECT user_id
+			FROM user_identities
+			WHERE issuer = $1 AND subject = $2
+		)`,
+		identity.Issuer,
+		identity.Subject,
+	))
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var email *string
+	if identity.Email != "" && identity.EmailVerified {
+		email = &identity.Email
+
+		existingUser, err := scanUser(tx.QueryRow(
+			ctx,
+			`SELECT `+userColumns+`
+			FROM users
+			WHERE lower(email) = lower($1)`,
+			identity.Email,
+		))
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return models.User{}, fmt.Errorf("%s: %w", op, err)
+		case existingUser.EmailVerifiedAt != nil:
+			user = existingUser
+		default:
+			// someone registered the address without proving it's theirs,
+			// the new user doesn't claim it
+			email = nil
+		}
+	}
+
+	if user.ID == 0 {
+		user, err = createOIDCUser(ctx, tx, identity, email)
+		if err != nil {
+			return models.User{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO user_identities (user_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4)`,
+		user.ID,
+		identity.Issuer,
+		identity.Subject,
+		identity.Email,
+	); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// createOIDCUser creates a user named after the identity, adding a numeric suffix
+// while the name is taken.
+func createOIDCUser(ctx context.Context, tx pgx.Tx, identity models.OIDCIdentity, email *string) (models.User, error) {
+	username := identity.PreferredUsername
+	if username == "" {
+		username, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if username == "" {
+		username = "user"
+	}
+
+	for i := range maxUsernameAttempts {
+		candidate := username
+		if i > 0 {
+			candidate = username + "-" + strconv.Itoa(i+1)
+		}
+
+		user, err := scanUser(tx.QueryRow(
+			ctx,
+			`INSERT INTO users (username, email, email_verified_at, password_hash)
+			VALUES ($1, $2, CASE WHEN $2::text IS NULL THEN NULL ELSE CURRENT_TIMESTAMP END, '')
+			ON CONFLICT (username) DO NOTHING
+			RETURNING `+userColumns,
+			candidate,
+			email,
+		))
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return models.User{}, err
+		}
+
+		return user, nil
+	}
+
+	return models.User{}, storage.ErrUserExist
+}
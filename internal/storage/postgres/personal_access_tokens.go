@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+func (s *Storage) SavePersonalAccessToken(ctx context.Context, userID int, name, tokenHash string, scopes []string, expiresAt *time.Time) (int64, error) {
+	const op = "storage.postgres.SavePersonalAccessToken"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	if err := s.pool.QueryRow(
+		ctx,
+		`INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		userID,
+		name,
+		tokenHash,
+		scopes,
+		expiresAt,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetPersonalAccessTokens(ctx context.Context, userID int) ([]models.PersonalAccessToken, error) {
+	const op = "storage.postgres.GetPersonalAccessTokens"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resTokens := make([]models.PersonalAccessToken, 0)
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT id, user_id, name, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token models.PersonalAccessToken
+
+		if err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.Scopes,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+			&token.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resTokens = append(resTokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resTokens, nil
+}
+
+func (s *Storage) RevokePersonalAccessToken(ctx context.Context, tokenID, userID int) (int64, error) {
+	const op = "storage.postgres.RevokePersonalAccessToken"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	err := s.pool.QueryRow(
+		ctx,
+		`UPDATE personal_access_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		RETURNING id`,
+		tokenID,
+		userID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoPersonalAccessToken)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UsePersonalAccessToken returns the active, unexpired token with tokenHash
+// and records when it was last used.
+func (s *Storage) UsePersonalAccessToken(ctx context.Context, tokenHash string) (models.PersonalAccessToken, error) {
+	const op = "storage.postgres.UsePersonalAccessToken"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var token models.PersonalAccessToken
+
+	err := s.pool.QueryRow(
+		ctx,
+		`UPDATE personal_access_tokens
+		SET last_used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		RETURNING id, user_id, name, scopes, expires_at, last_used_at, created_at`,
+		tokenHash,
+	).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.Scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, storage.ErrNoPersonalAccessToken)
+	}
+	if err != nil {
+		return models.PersonalAccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
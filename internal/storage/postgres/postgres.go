@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
+	"strings"
 	"time"
 	"todo/internal/models"
 	"todo/internal/storage"
@@ -20,9 +21,11 @@ const migrationDir = "internal/storage/migrations"
 type Storage struct {
 	pool            *pgxpool.Pool
 	standardTimeout time.Duration
+	// maxNoteRevisions is how many revisions are kept per note, 0 keeps all of them.
+	maxNoteRevisions int
 }
 
-func New(connectionString string, standardQueryTimeout time.Duration) (storage *Storage, err error) {
+func New(connectionString string, standardQueryTimeout time.Duration, maxNoteRevisions int) (storage *Storage, err error) {
 	const op = "storage.postgres.New"
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -46,10 +49,10 @@ func New(connectionString string, standardQueryTimeout time.Duration) (storage *
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &Storage{pool, standardQueryTimeout}, nil
+	return &Storage{pool, standardQueryTimeout, maxNoteRevisions}, nil
 }
 
-func (s *Storage) SaveUser(ctx context.Context, username string) (int64, error) {
+func (s *Storage) SaveUser(ctx context.Context, username, email, passwordHash string) (int64, error) {
 	const op = "storage.postgres.SaveUser"
 	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
 	defer cancel()
@@ -58,11 +61,16 @@ func (s *Storage) SaveUser(ctx context.Context, username string) (int64, error)
 
 	err := s.pool.QueryRow(
 		ctx,
-		`INSERT INTO users (username)
-		VALUES ($1)
+		`INSERT INTO users (username, email, password_hash)
+		VALUES ($1, $2, $3)
 		RETURNING id`,
 		username,
+		email,
+		passwordHash,
 	).Scan(&id)
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == "users_email_key" {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrEmailExist)
+	}
 	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
 		return 0, fmt.Errorf("%s: %w", op, storage.ErrUserExist)
 	}
@@ -73,20 +81,106 @@ func (s *Storage) SaveUser(ctx context.Context, username string) (int64, error)
 	return id, nil
 }
 
-func (s *Storage) SaveNote(ctx context.Context, userID int, title, content string) (int64, error) {
-	const op = "storage.postgres.SaveNote"
+const userColumns = `id, username, email, email_verified_at, password_hash, totp_enabled, role, suspended_at, created_at`
+
+func scanUser(row pgx.Row) (models.User, error) {
+	var user models.User
+
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.EmailVerifiedAt,
+		&user.PasswordHash,
+		&user.TOTPEnabled,
+		&user.Role,
+		&user.SuspendedAt,
+		&user.CreatedAt,
+	)
+
+	return user, err
+}
+
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	const op = "storage.postgres.GetUserByUsername"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	user, err := scanUser(s.pool.QueryRow(
+		ctx,
+		`SELECT `+userColumns+`
+		FROM users
+		WHERE username = $1`,
+		username,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	const op = "storage.postgres.GetUserByEmail"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	user, err := scanUser(s.pool.QueryRow(
+		ctx,
+		`SELECT `+userColumns+`
+		FROM users
+		WHERE lower(email) = lower($1)`,
+		email,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (s *Storage) GetUser(ctx context.Context, userID int) (models.User, error) {
+	const op = "storage.postgres.GetUser"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	user, err := scanUser(s.pool.QueryRow(
+		ctx,
+		`SELECT `+userColumns+`
+		FROM users
+		WHERE id = $1`,
+		userID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (s *Storage) SaveRefreshSession(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (int64, error) {
+	const op = "storage.postgres.SaveRefreshSession"
 	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
 	defer cancel()
 	var id int64
 
 	if err := s.pool.QueryRow(
 		ctx,
-		`INSERT INTO notes(user_id, title, content)
+		`INSERT INTO refresh_sessions (user_id, token_hash, expires_at)
 		VALUES ($1, $2, $3)
 		RETURNING id`,
 		userID,
-		title,
-		content,
+		tokenHash,
+		expiresAt,
 	).Scan(&id); err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -94,60 +188,455 @@ func (s *Storage) SaveNote(ctx context.Context, userID int, title, content strin
 	return id, nil
 }
 
-func (s *Storage) GetNotes(ctx context.Context, userID, limit, offset int, sort string) ([]models.Note, []int64, error) {
+// RotateRefreshSession marks the session with tokenHash as used and creates a new session
+// in the same family with newTokenHash. If tokenHash belongs to an already used session,
+// the whole family is revoked and storage.ErrRefreshTokenReused is returned.
+func (s *Storage) RotateRefreshSession(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (int64, error) {
+	const op = "storage.postgres.RotateRefreshSession"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id, userID int64
+	var familyID string
+	var sessionExpiresAt time.Time
+	var usedAt, revokedAt *time.Time
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(
+		ctx,
+		`SELECT id, user_id, family_id::text, expires_at, used_at, revoked_at
+		FROM refresh_sessions
+		WHERE token_hash = $1
+		FOR UPDATE`,
+		tokenHash,
+	).Scan(&id, &userID, &familyID, &sessionExpiresAt, &usedAt, &revokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrRefreshSessionNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if revokedAt != nil {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrRefreshSessionNotFound)
+	}
+
+	if usedAt != nil {
+		if _, err := tx.Exec(
+			ctx,
+			`UPDATE refresh_sessions
+			SET revoked_at = CURRENT_TIMESTAMP
+			WHERE family_id = $1 AND revoked_at IS NULL`,
+			familyID,
+		); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenReused)
+	}
+
+	if time.Now().After(sessionExpiresAt) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrRefreshSessionExpired)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE refresh_sessions
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		id,
+	); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO refresh_sessions (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		userID,
+		familyID,
+		newTokenHash,
+		expiresAt,
+	); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// RevokeRefreshSession revokes the whole family of the user's refresh session with tokenHash.
+func (s *Storage) RevokeRefreshSession(ctx context.Context, tokenHash string, userID int) error {
+	const op = "storage.postgres.RevokeRefreshSession"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tag, err := s.pool.Exec(
+		ctx,
+		`UPDATE refresh_sessions
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE revoked_at IS NULL AND family_id = (
+			SELECT family_id
+			FROM refresh_sessions
+			WHERE token_hash = $1 AND user_id = $2
+		)`,
+		tokenHash,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRefreshSessionNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) RevokeAccessToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	const op = "storage.postgres.RevokeAccessToken"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	if _, err := s.pool.Exec(
+		ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`,
+		tokenID,
+		userID,
+		expiresAt,
+	); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeUserTokens invalidates every access token issued to the user before now
+// and revokes all of the user's refresh sessions. It returns the new cutoff time.
+// The cutoff is truncated to seconds like the iat of tokens, so that tokens issued
+// in the same second, right after a logout or a password reset, stay valid.
+func (s *Storage) RevokeUserTokens(ctx context.Context, userID int) (time.Time, error) {
+	const op = "storage.postgres.RevokeUserTokens"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var validAfter time.Time
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE users
+		SET tokens_valid_after = date_trunc('second', CURRENT_TIMESTAMP)
+		WHERE id = $1
+		RETURNING tokens_valid_after`,
+		userID,
+	).Scan(&validAfter)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE refresh_sessions
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID,
+	); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return validAfter, nil
+}
+
+// IsTokenRevoked reports whether the access token was revoked by itself
+// or by revoking all tokens of its user, which tokens issued at the cutoff survive.
+func (s *Storage) IsTokenRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error) {
+	const op = "storage.postgres.IsTokenRevoked"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var revoked bool
+
+	if err := s.pool.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1
+			FROM revoked_tokens
+			WHERE jti = $1
+		) OR EXISTS (
+			SELECT 1
+			FROM users
+			WHERE id = $2 AND tokens_valid_after > $3
+		)`,
+		tokenID,
+		userID,
+		issuedAt,
+	).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+const noteColumns = `id, title, content, status, priority, due_at, completed_at,
+	COALESCE((
+		SELECT array_agg(t.name ORDER BY t.name)
+		FROM note_tags nt
+		JOIN tags t ON t.id = nt.tag_id
+		WHERE nt.note_id = notes.id
+	), '{}'),
+	project_id, rrule, timezone,
+	(SELECT count(*) FROM note_items WHERE note_id = notes.id AND done),
+	(SELECT count(*) FROM note_items WHERE note_id = notes.id),
+	created_at, updated_at, deleted_at, version`
+
+func scanNote(row pgx.Row) (models.Note, error) {
+	var note models.Note
+
+	err := row.Scan(
+		&note.ID,
+		&note.Title,
+		&note.Content,
+		&note.Status,
+		&note.Priority,
+		&note.DueAt,
+		&note.CompletedAt,
+		&note.Tags,
+		&note.ProjectID,
+		&note.RRule,
+		&note.Timezone,
+		&note.ItemsDone,
+		&note.ItemsTotal,
+		&note.CreatedAt,
+		&note.UpdatedAt,
+		&note.DeletedAt,
+		&note.Version,
+	)
+
+	return note, err
+}
+
+func noteStatus(status string) string {
+	if status == "" {
+		return models.NoteStatusOpen
+	}
+
+	return status
+}
+
+func (s *Storage) SaveNote(ctx context.Context, userID int, note models.Request) (int64, error) {
+	const op = "storage.postgres.SaveNote"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, _, err := s.insertNote(ctx, tx, userID, note)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// insertNote returns the id and the version of the new note.
+func (s *Storage) insertNote(ctx context.Context, tx pgx.Tx, userID int, note models.Request) (int64, int64, error) {
+	var id, version int64
+
+	if err := checkProject(ctx, tx, userID, note.ProjectID); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`INSERT INTO notes(user_id, title, content, status, priority, due_at, project_id, rrule, timezone,
+			completed_at, recurrence_start)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+			CASE WHEN $4 = 'done' THEN CURRENT_TIMESTAMP END, CASE WHEN $8 <> '' THEN $6::timestamptz END)
+		RETURNING id, version`,
+		userID,
+		note.Title,
+		note.Content,
+		noteStatus(note.Status),
+		note.Priority,
+		note.DueAt,
+		note.ProjectID,
+		note.RRule,
+		note.Timezone,
+	).Scan(&id, &version); err != nil {
+		return 0, 0, err
+	}
+
+	if err := s.saveNoteRevision(ctx, tx, id); err != nil {
+		return 0, 0, err
+	}
+
+	if err := setNoteTags(ctx, tx, userID, id, note.Tags); err != nil {
+		return 0, 0, err
+	}
+
+	return id, version, nil
+}
+
+// GetNotes returns a page of notes ordered by sort and the cursor of the next page,
+// which is empty on the last page. An empty cursor starts from the first page.
+func (s *Storage) GetNotes(ctx context.Context, userID, limit int, cursor string, sort []models.SortKey, filter models.NoteFilter) ([]models.Note, string, error) {
 	const op = "storage.postgres.GetNotes"
 	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
 	defer cancel()
-	resNotes := make([]models.Note, 0, limit)
-	resIDs := make([]int64, 0, limit)
+	resNotes := make([]models.Note, 0, limit+1)
+	var after []string
+	var q query
 
-	safeQuery := fmt.Sprintf(
-		`SELECT id, title, content, created_at, updated_at
-		FROM notes
-		WHERE user_id = $1
-		ORDER BY created_at %s
-		LIMIT $2
-		OFFSET $3`,
-		sort,
+	keys, ok := noteSortKeys(sort)
+	if !ok {
+		return nil, "", fmt.Errorf("%s: %w", op, storage.ErrInvalidSort)
+	}
+
+	if cursor != "" {
+		after, ok = decodeNoteCursor(cursor, keys)
+		if !ok {
+			return nil, "", fmt.Errorf("%s: %w", op, storage.ErrInvalidCursor)
+		}
+	}
+
+	q.where("user_id = ? AND deleted_at IS NULL", userID)
+
+	if len(filter.Statuses) > 0 {
+		q.where("status = ANY(?)", filter.Statuses)
+	}
+	if filter.Priority != nil {
+		q.where("priority = ?", *filter.Priority)
+	}
+	if filter.DueBefore != nil {
+		q.where("due_at < ?", *filter.DueBefore)
+	}
+	if filter.DueAfter != nil {
+		q.where("due_at > ?", *filter.DueAfter)
+	}
+	if filter.CreatedAfter != nil {
+		q.where("created_at > ?", *filter.CreatedAfter)
+	}
+	if filter.UpdatedSince != nil {
+		q.where("updated_at >= ?", *filter.UpdatedSince)
+	}
+	if filter.TitleContains != "" {
+		q.where("strpos(lower(title), lower(?)) > 0", filter.TitleContains)
+	}
+	if filter.Inbox {
+		q.where("project_id IS NULL")
+	}
+	if filter.ProjectID != nil && filter.Subprojects {
+		q.where(
+			`project_id IN (
+				WITH RECURSIVE subprojects AS (
+					SELECT id FROM projects WHERE id = ? AND user_id = ?
+					UNION ALL
+					SELECT p.id FROM projects p JOIN subprojects s ON p.parent_id = s.id
+				)
+				SELECT id FROM subprojects
+			)`,
+			*filter.ProjectID,
+			userID,
+		)
+	} else if filter.ProjectID != nil {
+		q.where("project_id = ?", *filter.ProjectID)
+	}
+	if tags := normalizeTags(filter.Tags); len(tags) > 0 {
+		tagged := `(
+			SELECT count(*)
+			FROM note_tags nt
+			JOIN tags t ON t.id = nt.tag_id
+			WHERE nt.note_id = notes.id AND t.name = ANY(?)
+		)`
+
+		if filter.TagMode == models.TagModeAll {
+			q.where(tagged+" = ?", tags, len(tags))
+		} else {
+			q.where(tagged+" > 0", tags)
+		}
+	}
+
+	orderNotes(&q, keys, after)
+
+	// one more note tells whether there is a next page
+	sql, args := q.build(
+		`SELECT `+noteColumns+`
+		FROM notes`,
+		limit+1,
 	)
 
-	rows, err := s.pool.Query(ctx, safeQuery, userID, limit, offset)
+	rows, err := s.pool.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("%s: %w", op, err)
+		return nil, "", fmt.Errorf("%s: %w", op, cursorErr(err, after))
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var note models.Note
-
-		if err := rows.Scan(&note.ID, &note.Title, &note.Content, &note.CreatedAt, &note.UpdatedAt); err != nil {
-			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", op, err)
 		}
 
 		resNotes = append(resNotes, note)
-		resIDs = append(resIDs, note.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", op, cursorErr(err, after))
 	}
 
-	if len(resNotes) == 0 {
-		return nil, nil, fmt.Errorf("%s: %w", op, storage.ErrUserNoNotes)
+	if len(resNotes) <= limit {
+		return resNotes, "", nil
 	}
 
-	return resNotes, resIDs, nil
+	resNotes = resNotes[:limit]
+
+	return resNotes, encodeNoteCursor(keys, resNotes[limit-1]), nil
 }
 
 func (s *Storage) GetNote(ctx context.Context, noteID, userID int) (models.Note, int64, error) {
 	const op = "storage.postgres.GetNote"
 	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
 	defer cancel()
-	var note models.Note
 
-	err := s.pool.QueryRow(
+	note, err := scanNote(s.pool.QueryRow(
 		ctx,
-		`SELECT id, title, content, created_at, updated_at
+		`SELECT `+noteColumns+`
 		FROM notes
-		WHERE id = $1 AND user_id = $2`,
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`,
 		noteID,
 		userID,
-	).Scan(&note.ID, &note.Title, &note.Content, &note.CreatedAt, &note.UpdatedAt)
+	))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return models.Note{}, 0, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
 	}
@@ -158,53 +647,334 @@ func (s *Storage) GetNote(ctx context.Context, noteID, userID int) (models.Note,
 	return note, note.ID, nil
 }
 
-func (s *Storage) UpdateNote(ctx context.Context, noteID, userID int, title, content string) (int64, error) {
+// lockNote locks the note for the rest of tx. A non-zero version must be the current one.
+func lockNote(ctx context.Context, tx pgx.Tx, noteID, userID int, version int64) error {
+	var current int64
+
+	err := tx.QueryRow(
+		ctx,
+		`SELECT version
+		FROM notes
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		FOR UPDATE`,
+		noteID,
+		userID,
+	).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.ErrNoNotes
+	}
+	if err != nil {
+		return err
+	}
+
+	if version != 0 && version != current {
+		return storage.ErrNoteVersionMismatch
+	}
+
+	return nil
+}
+
+// UpdateNote replaces the note and records its new title and content as a revision
+// if either has changed.
+// completed_at is kept while the note stays done. A non-zero version must be
+// the current version of the note. The new version is returned.
+func (s *Storage) UpdateNote(ctx context.Context, noteID, userID int, note models.Request, version int64) (int64, error) {
 	const op = "storage.postgres.UpdateNote"
 	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
 	defer cancel()
-	var id int64
 
-	err := s.pool.QueryRow(
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	newVersion, err := s.updateNote(ctx, tx, noteID, userID, note, version)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return newVersion, nil
+}
+
+func (s *Storage) updateNote(ctx context.Context, tx pgx.Tx, noteID, userID int, note models.Request, version int64) (int64, error) {
+	var newVersion int64
+	var oldTitle, oldContent string
+
+	if err := lockNote(ctx, tx, noteID, userID, version); err != nil {
+		return 0, err
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`SELECT title, content
+		FROM notes
+		WHERE id = $1`,
+		noteID,
+	).Scan(&oldTitle, &oldContent); err != nil {
+		return 0, err
+	}
+
+	if err := checkProject(ctx, tx, userID, note.ProjectID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.QueryRow(
 		ctx,
 		`UPDATE notes
-		SET title = $1, content = $2
-		WHERE id = $3 AND user_id = $4
-		RETURNING id`,
-		title,
-		content,
+		SET title = $1,
+			content = $2,
+			status = $3,
+			priority = $4,
+			due_at = $5,
+			project_id = $6,
+			rrule = $8,
+			timezone = $9,
+			completed_at = CASE WHEN $3 = 'done' THEN COALESCE(completed_at, CURRENT_TIMESTAMP) END,
+			recurrence_start = `+recurrenceStart("$8", "$9", "$5")+`
+		WHERE id = $7
+		RETURNING version`,
+		note.Title,
+		note.Content,
+		noteStatus(note.Status),
+		note.Priority,
+		note.DueAt,
+		note.ProjectID,
 		noteID,
-		userID,
-	).Scan(&id)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
+		note.RRule,
+		note.Timezone,
+	).Scan(&newVersion); err != nil {
+		return 0, err
+	}
+
+	if note.Title != oldTitle || note.Content != oldContent {
+		if err := s.saveNoteRevision(ctx, tx, int64(noteID)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := setNoteTags(ctx, tx, userID, int64(noteID), note.Tags); err != nil {
+		return 0, err
+	}
+
+	if err := s.repeatNote(ctx, tx, userID, int64(noteID)); err != nil {
+		return 0, err
 	}
+
+	return newVersion, nil
+}
+
+// PatchNote writes only the fields of note listed in fields, which are models.NoteField* names.
+// A non-zero version must be the current version of the note.
+func (s *Storage) PatchNote(ctx context.Context, noteID, userID int, note models.Request, fields []string, version int64) (models.Note, error) {
+	const op = "storage.postgres.PatchNote"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var q query
+	var sets []string
+	var tagsChanged, textChanged, projectChanged, recurrenceChanged bool
+
+	for _, field := range fields {
+		switch field {
+		case models.NoteFieldTitle:
+			sets = append(sets, "title = "+q.arg(note.Title))
+			textChanged = true
+		case models.NoteFieldContent:
+			sets = append(sets, "content = "+q.arg(note.Content))
+			textChanged = true
+		case models.NoteFieldStatus:
+			status := q.arg(noteStatus(note.Status))
+			sets = append(
+				sets,
+				"status = "+status,
+				"completed_at = CASE WHEN "+status+"::text = 'done' THEN COALESCE(completed_at, CURRENT_TIMESTAMP) END",
+			)
+		case models.NoteFieldPriority:
+			sets = append(sets, "priority = "+q.arg(note.Priority))
+		case models.NoteFieldDueAt:
+			sets = append(sets, "due_at = "+q.arg(note.DueAt))
+			recurrenceChanged = true
+		case models.NoteFieldTags:
+			tagsChanged = true
+		case models.NoteFieldProject:
+			sets = append(sets, "project_id = "+q.arg(note.ProjectID))
+			projectChanged = true
+		case models.NoteFieldRRule:
+			sets = append(sets, "rrule = "+q.arg(note.RRule))
+			recurrenceChanged = true
+		case models.NoteFieldTimezone:
+			sets = append(sets, "timezone = "+q.arg(note.Timezone))
+			recurrenceChanged = true
+		default:
+			return models.Note{}, fmt.Errorf("%s: unknown note field %q", op, field)
+		}
+	}
+
+	// note holds all fields, not only the changed ones
+	if recurrenceChanged {
+		sets = append(sets, "recurrence_start = "+recurrenceStart(q.arg(note.RRule), q.arg(note.Timezone), q.arg(note.DueAt)))
+	}
+
+	// a change of the tags alone still has to bump the version
+	if len(sets) == 0 {
+		sets = append(sets, "title = title")
+	}
+
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback(ctx)
 
-	return id, nil
+	if err := lockNote(ctx, tx, noteID, userID, version); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if projectChanged {
+		if err := checkProject(ctx, tx, userID, note.ProjectID); err != nil {
+			return models.Note{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// tags go first, so that the returned note has the new ones
+	if tagsChanged {
+		if err := setNoteTags(ctx, tx, userID, int64(noteID), note.Tags); err != nil {
+			return models.Note{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	resNote, err := scanNote(tx.QueryRow(
+		ctx,
+		`UPDATE notes
+		SET `+strings.Join(sets, ", ")+`
+		WHERE id = `+q.arg(noteID)+`
+		RETURNING `+noteColumns,
+		q.args...,
+	))
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if textChanged {
+		if err := s.saveNoteRevision(ctx, tx, resNote.ID); err != nil {
+			return models.Note{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.repeatNote(ctx, tx, userID, resNote.ID); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resNote, nil
 }
 
-func (s *Storage) DeleteNote(ctx context.Context, noteID, userID int) (int64, error) {
-	const op = "storage.postgres.DeleteNote"
+// CompleteNote marks the note done, and with items all its checklist items too.
+// Completing a done note keeps its completed_at. A recurring note gets its next occurrence.
+func (s *Storage) CompleteNote(ctx context.Context, noteID, userID int, items bool) (models.Note, error) {
+	const op = "storage.postgres.CompleteNote"
 	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
 	defer cancel()
-	var id int64
 
-	err := s.pool.QueryRow(
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	note, err := s.completeNote(ctx, tx, noteID, userID, 0, items)
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, nil
+}
+
+func (s *Storage) completeNote(ctx context.Context, tx pgx.Tx, noteID, userID int, version int64, items bool) (models.Note, error) {
+	if err := lockNote(ctx, tx, noteID, userID, version); err != nil {
+		return models.Note{}, err
+	}
+
+	if items {
+		if _, err := tx.Exec(
+			ctx,
+			`UPDATE note_items
+			SET done = true,
+				completed_at = CURRENT_TIMESTAMP
+			WHERE note_id = $1 AND NOT done`,
+			noteID,
+		); err != nil {
+			return models.Note{}, err
+		}
+	}
+
+	note, err := scanNote(tx.QueryRow(
 		ctx,
-		`DELETE FROM notes
-		WHERE id = $1 AND user_id = $2
-		RETURNING id`,
+		`UPDATE notes
+		SET status = 'done',
+			completed_at = COALESCE(completed_at, CURRENT_TIMESTAMP)
+		WHERE id = $1
+		RETURNING `+noteColumns,
 		noteID,
-		userID,
-	).Scan(&id)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
+	))
+	if err != nil {
+		return models.Note{}, err
 	}
+
+	if err := s.repeatNote(ctx, tx, userID, note.ID); err != nil {
+		return models.Note{}, err
+	}
+
+	return note, nil
+}
+
+// DeleteNote moves the note to the trash, see RestoreNote and PurgeNote.
+// A non-zero version must be the current version of the note.
+func (s *Storage) DeleteNote(ctx context.Context, noteID, userID int, version int64) (int64, error) {
+	const op = "storage.postgres.DeleteNote"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback(ctx)
 
-	return id, nil
+	if err := deleteNote(ctx, tx, noteID, userID, version); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int64(noteID), nil
+}
+
+func deleteNote(ctx context.Context, tx pgx.Tx, noteID, userID int, version int64) error {
+	if err := lockNote(ctx, tx, noteID, userID, version); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE notes
+		SET deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		noteID,
+	)
+
+	return err
 }
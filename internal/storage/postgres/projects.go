@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// projectTreeLock serializes the moves of one user's projects, so that two concurrent
+// moves can't nest two projects in each other.
+const projectTreeLock = 1
+
+const projectColumns = `id, parent_id, name, created_at, updated_at`
+
+func scanProject(row pgx.Row) (models.Project, error) {
+	var project models.Project
+
+	err := row.Scan(&project.ID, &project.ParentID, &project.Name, &project.CreatedAt, &project.UpdatedAt)
+
+	return project, err
+}
+
+// checkProject makes sure a note can be put in the project, which must be the user's own.
+// A nil project is the inbox.
+func checkProject(ctx context.Context, tx pgx.Tx, userID int, projectID *int64) error {
+	var exists bool
+
+	if projectID == nil {
+		return nil
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1
+			FROM projects
+			WHERE id = $1 AND user_id = $2
+		)`,
+		*projectID,
+		userID,
+	).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return storage.ErrNoProject
+	}
+
+	return nil
+}
+
+// checkParent makes sure the project can be nested in parentID: the parent must be
+// the user's own and must not be the project itself or one of its subprojects.
+// projectID is 0 for a new project.
+func checkParent(ctx context.Context, tx pgx.Tx, projectID, userID int, parentID *int64) error {
+	var exists, cycle bool
+
+	if parentID == nil {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, projectTreeLock, userID); err != nil {
+		return err
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1
+			FROM projects
+			WHERE id = $1 AND user_id = $2
+		)`,
+		*parentID,
+		userID,
+	).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return storage.ErrNoParentProject
+	}
+
+	if projectID == 0 {
+		return nil
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`WITH RECURSIVE subprojects AS (
+			SELECT id FROM projects WHERE id = $1
+			UNION ALL
+			SELECT p.id FROM projects p JOIN subprojects s ON p.parent_id = s.id
+		)
+		SELECT EXISTS (
+			SELECT 1
+			FROM subprojects
+			WHERE id = $2
+		)`,
+		projectID,
+		*parentID,
+	).Scan(&cycle); err != nil {
+		return err
+	}
+
+	if cycle {
+		return storage.ErrProjectCycle
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveProject(ctx context.Context, userID int, project models.ProjectRequest) (int64, error) {
+	const op = "storage.postgres.SaveProject"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkParent(ctx, tx, 0, userID, project.ParentID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`INSERT INTO projects (user_id, parent_id, name)
+		VALUES ($1, $2, $3)
+		RETURNING id`,
+		userID,
+		project.ParentID,
+		strings.TrimSpace(project.Name),
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetProjects returns all projects of the user, the tree is built from their parent ids.
+func (s *Storage) GetProjects(ctx context.Context, userID int) ([]models.Project, error) {
+	const op = "storage.postgres.GetProjects"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resProjects := make([]models.Project, 0)
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT `+projectColumns+`
+		FROM projects
+		WHERE user_id = $1
+		ORDER BY name, id`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resProjects = append(resProjects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resProjects, nil
+}
+
+func (s *Storage) GetProject(ctx context.Context, projectID, userID int) (models.Project, error) {
+	const op = "storage.postgres.GetProject"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	project, err := scanProject(s.pool.QueryRow(
+		ctx,
+		`SELECT `+projectColumns+`
+		FROM projects
+		WHERE id = $1 AND user_id = $2`,
+		projectID,
+		userID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Project{}, fmt.Errorf("%s: %w", op, storage.ErrNoProject)
+	}
+	if err != nil {
+		return models.Project{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return project, nil
+}
+
+// UpdateProject renames the project and moves it under project.ParentID,
+// or to the top level without it. Its subprojects and notes move with it.
+func (s *Storage) UpdateProject(ctx context.Context, projectID, userID int, project models.ProjectRequest) (int64, error) {
+	const op = "storage.postgres.UpdateProject"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkParent(ctx, tx, projectID, userID, project.ParentID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE projects
+		SET name = $1,
+			parent_id = $2
+		WHERE id = $3 AND user_id = $4
+		RETURNING id`,
+		strings.TrimSpace(project.Name),
+		project.ParentID,
+		projectID,
+		userID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoProject)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// DeleteProject deletes the project with all its subprojects. Their notes go to the inbox
+// with models.ProjectNotesInbox or to the trash with models.ProjectNotesTrash.
+func (s *Storage) DeleteProject(ctx context.Context, projectID, userID int, notes string) (int64, error) {
+	const op = "storage.postgres.DeleteProject"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var projectIDs []int64
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(
+		ctx,
+		`WITH RECURSIVE subprojects AS (
+			SELECT id FROM projects WHERE id = $1 AND user_id = $2
+			UNION ALL
+			SELECT p.id FROM projects p JOIN subprojects s ON p.parent_id = s.id
+		)
+		SELECT COALESCE(array_agg(id), '{}')
+		FROM subprojects`,
+		projectID,
+		userID,
+	).Scan(&projectIDs); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(projectIDs) == 0 {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoProject)
+	}
+
+	// notes already in the trash keep the time they were deleted at
+	deletedAt := "deleted_at"
+	if notes == models.ProjectNotesTrash {
+		deletedAt = "COALESCE(deleted_at, CURRENT_TIMESTAMP)"
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE notes
+		SET project_id = NULL,
+			deleted_at = `+deletedAt+`
+		WHERE project_id = ANY($1)`,
+		projectIDs,
+	); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM projects
+		WHERE id = $1`,
+		projectID,
+	); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int64(projectID), nil
+}
+
+// MoveNote puts the note in the project, or in the inbox with a nil projectID.
+func (s *Storage) MoveNote(ctx context.Context, noteID, userID int, projectID *int64) (models.Note, error) {
+	const op = "storage.postgres.MoveNote"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockNote(ctx, tx, noteID, userID, 0); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := checkProject(ctx, tx, userID, projectID); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	note, err := scanNote(tx.QueryRow(
+		ctx,
+		`UPDATE notes
+		SET project_id = $1
+		WHERE id = $2
+		RETURNING `+noteColumns,
+		projectID,
+		noteID,
+	))
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, nil
+}
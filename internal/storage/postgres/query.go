@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+)
+
+// query builds a SELECT whose values all go in as placeholders. Conditions are
+// written with ? in place of values, so a filter never has to put a value
+// or a placeholder number into the SQL text by hand.
+type query struct {
+	conditions []string
+	orderBy    []string
+	args       []any
+}
+
+// arg adds a value and returns its placeholder.
+func (q *query) arg(value any) string {
+	q.args = append(q.args, value)
+
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// where adds a condition, replacing every ? in cond with the placeholder of the next value.
+func (q *query) where(cond string, values ...any) {
+	var b strings.Builder
+
+	for _, r := range cond {
+		if r == '?' && len(values) > 0 {
+			b.WriteString(q.arg(values[0]))
+			values = values[1:]
+
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	q.conditions = append(q.conditions, b.String())
+}
+
+// whereAny adds the alternatives as one condition, they are built with their own where calls
+// on a query that shares the args of q.
+func (q *query) whereAny(alternatives func(or *query)) {
+	or := &query{args: q.args}
+	alternatives(or)
+	q.args = or.args
+
+	if len(or.conditions) == 0 {
+		return
+	}
+
+	q.conditions = append(q.conditions, "("+strings.Join(or.conditions, " OR ")+")")
+}
+
+// order adds an ORDER BY expression. expr must be SQL written by us, never a value.
+func (q *query) order(expr string, desc bool) {
+	if desc {
+		expr += " DESC"
+	}
+
+	q.orderBy = append(q.orderBy, expr)
+}
+
+// build appends the conditions, the order and the limit to head, which is
+// the SELECT ... FROM ... part of the statement.
+func (q *query) build(head string, limit int) (string, []any) {
+	var b strings.Builder
+
+	b.WriteString(head)
+
+	if len(q.conditions) > 0 {
+		b.WriteString("\nWHERE ")
+		b.WriteString(strings.Join(q.conditions, "\n\tAND "))
+	}
+
+	if len(q.orderBy) > 0 {
+		b.WriteString("\nORDER BY ")
+		b.WriteString(strings.Join(q.orderBy, ", "))
+	}
+
+	if limit > 0 {
+		b.WriteString("\nLIMIT ")
+		b.WriteString(q.arg(limit))
+	}
+
+	return b.String(), q.args
+}
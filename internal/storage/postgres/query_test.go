@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestQueryBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(q *query)
+		limit    int
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:    "nothing",
+			build:   func(q *query) {},
+			wantSQL: "SELECT id FROM notes",
+		},
+		{
+			name: "conditions in order",
+			build: func(q *query) {
+				q.where("user_id = ?", 1)
+				q.where("deleted_at IS NULL")
+				q.where("priority BETWEEN ? AND ?", 1, 3)
+			},
+			limit:    10,
+			wantSQL:  "SELECT id FROM notes\nWHERE user_id = $1\n\tAND deleted_at IS NULL\n\tAND priority BETWEEN $2 AND $3\nLIMIT $4",
+			wantArgs: []any{1, 1, 3, 10},
+		},
+		{
+			name: "alternatives share the placeholders",
+			build: func(q *query) {
+				q.where("user_id = ?", 1)
+				q.whereAny(func(or *query) {
+					or.where("title ILIKE ?", "%a%")
+					or.where("content ILIKE ?", "%a%")
+				})
+				q.where("status = ?", "open")
+			},
+			wantSQL:  "SELECT id FROM notes\nWHERE user_id = $1\n\tAND (title ILIKE $2 OR content ILIKE $3)\n\tAND status = $4",
+			wantArgs: []any{1, "%a%", "%a%", "open"},
+		},
+		{
+			name: "no alternatives",
+			build: func(q *query) {
+				q.whereAny(func(or *query) {})
+			},
+			wantSQL: "SELECT id FROM notes",
+		},
+		{
+			name: "order",
+			build: func(q *query) {
+				q.order("updated_at", true)
+				q.order("id", false)
+			},
+			limit:    5,
+			wantSQL:  "SELECT id FROM notes\nORDER BY updated_at DESC, id\nLIMIT $1",
+			wantArgs: []any{5},
+		},
+		{
+			name: "a value is never put in the sql",
+			build: func(q *query) {
+				q.where("title = ?", "'; DROP TABLE notes; --")
+			},
+			wantSQL:  "SELECT id FROM notes\nWHERE title = $1",
+			wantArgs: []any{"'; DROP TABLE notes; --"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q query
+			tt.build(&q)
+
+			sql, args := q.build("SELECT id FROM notes", tt.limit)
+			if sql != tt.wantSQL {
+				t.Errorf("build() sql =\n%s\nwant\n%s", sql, tt.wantSQL)
+			}
+
+			if !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("build() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestQueryArg(t *testing.T) {
+	var q query
+
+	if got := q.arg("a"); got != "$1" {
+		t.Errorf("arg() = %s, want $1", got)
+	}
+
+	if got := q.arg("b"); got != "$2" {
+		t.Errorf("arg() = %s, want $2", got)
+	}
+}
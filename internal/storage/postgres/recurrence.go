@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"todo/internal/models"
+	"todo/pkg/rrule"
+)
+
+// recurrenceStart is the SQL for the new recurrence_start of a note, given the
+// placeholders of its new rule, timezone and due time. The start of the series
+// is kept while the rule and timezone stay the same, so that COUNT and UNTIL
+// are counted from the first occurrence.
+func recurrenceStart(rule, timezone, dueAt string) string {
+	return `CASE
+			WHEN ` + rule + `::text = '' THEN NULL
+			WHEN recurrence_start IS NULL OR rrule <> ` + rule + `::text OR timezone <> ` + timezone + `::text
+				THEN ` + dueAt + `::timestamptz
+			ELSE recurrence_start
+		END`
+}
+
+// repeatNote creates the next occurrence of a done recurring note, unless the note
+// had one already or its rule has ended. The occurrence is an open copy of the note
+// with the checklist unchecked and the reminders relative to due_at, due at the first
+// time of the rule after the note. The note is marked with next_occurrence_created_at,
+// which outlives the occurrence, so a purged occurrence isn't created again.
+func (s *Storage) repeatNote(ctx context.Context, tx pgx.Tx, userID int, noteID int64) error {
+	var note models.Request
+	var start time.Time
+	var id int64
+
+	err := tx.QueryRow(
+		ctx,
+		`SELECT title, content, priority, due_at, project_id, rrule, timezone,
+			COALESCE(recurrence_start, due_at),
+			COALESCE((
+				SELECT array_agg(t.name ORDER BY t.name)
+				FROM note_tags nt
+				JOIN tags t ON t.id = nt.tag_id
+				WHERE nt.note_id = notes.id
+			), '{}')
+		FROM notes
+		WHERE id = $1 AND status = 'done' AND rrule <> '' AND due_at IS NOT NULL
+			AND next_occurrence_created_at IS NULL
+		FOR UPDATE`,
+		noteID,
+	).Scan(
+		&note.Title,
+		&note.Content,
+		&note.Priority,
+		&note.DueAt,
+		&note.ProjectID,
+		&note.RRule,
+		&note.Timezone,
+		&start,
+		&note.Tags,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rule, err := rrule.Parse(note.RRule)
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(note.Timezone)
+	if err != nil {
+		return err
+	}
+
+	next, ok := rule.Next(start.In(loc), note.DueAt.In(loc))
+	if !ok {
+		return nil
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE notes SET next_occurrence_created_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		noteID,
+	); err != nil {
+		return err
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		`INSERT INTO notes(user_id, title, content, status, priority, due_at, project_id, rrule, timezone,
+			recurrence_start, previous_occurrence_id)
+		VALUES ($1, $2, $3, 'open', $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		userID,
+		note.Title,
+		note.Content,
+		note.Priority,
+		next,
+		note.ProjectID,
+		note.RRule,
+		note.Timezone,
+		start,
+		noteID,
+	).Scan(&id); err != nil {
+		return err
+	}
+
+	if err := s.saveNoteRevision(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := setNoteTags(ctx, tx, userID, id, note.Tags); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO note_items (note_id, position, text)
+		SELECT $1, position, text
+		FROM note_items
+		WHERE note_id = $2`,
+		id,
+		noteID,
+	); err != nil {
+		return err
+	}
+
+	// reminders at a fixed time don't carry over, the ones relative to due_at do
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO reminders (note_id, due_offset, channel, webhook_url, fire_at)
+		SELECT $1, due_offset, channel, webhook_url, $3::timestamptz + make_interval(secs => due_offset)
+		FROM reminders
+		WHERE note_id = $2 AND due_offset IS NOT NULL`,
+		id,
+		noteID,
+		next,
+	)
+
+	return err
+}
@@ -0,0 +1,279 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+const reminderColumns = `r.id, r.note_id, r.remind_at, r.due_offset, r.channel, r.webhook_url, r.fire_at,
+	r.attempts, r.last_error, r.sent_at, r.failed_at, r.created_at`
+
+func scanReminder(row pgx.Row, dest ...any) (models.Reminder, error) {
+	var reminder models.Reminder
+	var offset *int
+
+	err := row.Scan(append([]any{
+		&reminder.ID,
+		&reminder.NoteID,
+		&reminder.At,
+		&offset,
+		&reminder.Channel,
+		&reminder.WebhookURL,
+		&reminder.FireAt,
+		&reminder.Attempts,
+		&reminder.LastError,
+		&reminder.SentAt,
+		&reminder.FailedAt,
+		&reminder.CreatedAt,
+	}, dest...)...)
+
+	if offset != nil {
+		reminder.Offset = (time.Duration(*offset) * time.Second).String()
+	}
+
+	return reminder, err
+}
+
+// SaveReminder adds a reminder to the note, at reminder.At or offset from its due_at.
+// A reminder at an offset needs a note with a due_at.
+func (s *Storage) SaveReminder(ctx context.Context, noteID, userID int, reminder models.ReminderRequest, offset *time.Duration) (int64, error) {
+	const op = "storage.postgres.SaveReminder"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var offsetSeconds *int
+	var id int64
+
+	if offset != nil {
+		seconds := int(offset.Seconds())
+		offsetSeconds = &seconds
+	}
+
+	err := s.pool.QueryRow(
+		ctx,
+		`INSERT INTO reminders (note_id, remind_at, due_offset, channel, webhook_url, fire_at)
+		SELECT id, $3, $4, $5, $6, COALESCE($3::timestamptz, due_at + make_interval(secs => $4::int))
+		FROM notes
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND ($4::int IS NULL OR due_at IS NOT NULL)
+		RETURNING id`,
+		noteID,
+		userID,
+		reminder.At,
+		offsetSeconds,
+		reminder.Channel,
+		reminder.WebhookURL,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+
+		if err := s.pool.QueryRow(
+			ctx,
+			`SELECT EXISTS (
+				SELECT 1
+				FROM notes
+				WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			)`,
+			noteID,
+			userID,
+		).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if exists {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrNoDueAt)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetReminders(ctx context.Context, noteID, userID int) ([]models.Reminder, error) {
+	const op = "storage.postgres.GetReminders"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resReminders := make([]models.Reminder, 0)
+	var exists bool
+
+	if err := s.pool.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1
+			FROM notes
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		)`,
+		noteID,
+		userID,
+	).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
+	}
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT `+reminderColumns+`
+		FROM reminders r
+		WHERE r.note_id = $1
+		ORDER BY r.fire_at NULLS LAST, r.id`,
+		noteID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resReminders = append(resReminders, reminder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resReminders, nil
+}
+
+func (s *Storage) DeleteReminder(ctx context.Context, reminderID, noteID, userID int) (int64, error) {
+	const op = "storage.postgres.DeleteReminder"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	err := s.pool.QueryRow(
+		ctx,
+		`DELETE FROM reminders r
+		USING notes n
+		WHERE r.id = $1 AND r.note_id = $2 AND n.id = r.note_id AND n.user_id = $3 AND n.deleted_at IS NULL
+		RETURNING r.id`,
+		reminderID,
+		noteID,
+		userID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoReminder)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// FireReminder claims the reminder that has been due the longest and hands it to fire,
+// then records the result. The reminder stays locked until then and other instances
+// skip it. Notifications saved through notify are committed with the result, so they
+// are saved exactly once. Reminders of done or deleted notes are not fired.
+// It returns false when no reminder is due.
+func (s *Storage) FireReminder(ctx context.Context, fire func(ctx context.Context, reminder models.DueReminder, notify func(ctx context.Context, title, body string) error) models.ReminderResult) (bool, error) {
+	const op = "storage.postgres.FireReminder"
+	var reminder models.DueReminder
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	claimCtx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	reminder.Reminder, err = scanReminder(
+		tx.QueryRow(
+			claimCtx,
+			`SELECT `+reminderColumns+`, u.id, u.username, u.email, n.title, n.due_at
+			FROM reminders r
+			JOIN notes n ON n.id = r.note_id
+			JOIN users u ON u.id = n.user_id
+			WHERE r.sent_at IS NULL AND r.failed_at IS NULL
+				AND COALESCE(r.retry_at, r.fire_at) <= CURRENT_TIMESTAMP
+				AND n.deleted_at IS NULL AND n.status <> 'done'
+			ORDER BY COALESCE(r.retry_at, r.fire_at)
+			LIMIT 1
+			FOR UPDATE OF r SKIP LOCKED`,
+		),
+		&reminder.UserID,
+		&reminder.Username,
+		&reminder.Email,
+		&reminder.NoteTitle,
+		&reminder.NoteDueAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// in a savepoint, so that a failed notification is recorded as a failed delivery
+	result := fire(ctx, reminder, func(ctx context.Context, title, body string) error {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := saveNotification(ctx, savepoint, reminder.UserID, &reminder.NoteID, title, body); err != nil {
+			savepoint.Rollback(ctx)
+
+			return err
+		}
+
+		return savepoint.Commit(ctx)
+	})
+
+	var lastError string
+	var sentAt, retryAt, failedAt *time.Time
+	now := time.Now()
+
+	switch {
+	case result.Err == nil:
+		sentAt = &now
+	case result.RetryAt.IsZero():
+		lastError = result.Err.Error()
+		failedAt = &now
+	default:
+		lastError = result.Err.Error()
+		retryAt = &result.RetryAt
+	}
+
+	doneCtx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	if _, err := tx.Exec(
+		doneCtx,
+		`UPDATE reminders
+		SET attempts = attempts + 1,
+			last_error = $1,
+			sent_at = $2,
+			retry_at = $3,
+			failed_at = $4
+		WHERE id = $5`,
+		lastError,
+		sentAt,
+		retryAt,
+		failedAt,
+		reminder.ID,
+	); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(doneCtx); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// saveNoteRevision records the current title and content of the note as its next revision
+// and drops the oldest revisions beyond s.maxNoteRevisions. The caller must hold the
+// row lock of the note, which keeps revision numbers from racing.
+func (s *Storage) saveNoteRevision(ctx context.Context, tx pgx.Tx, noteID int64) error {
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO note_revisions(note_id, revision, title, content)
+		SELECT id, COALESCE((SELECT max(revision) FROM note_revisions WHERE note_id = $1), 0) + 1, title, content
+		FROM notes
+		WHERE id = $1`,
+		noteID,
+	); err != nil {
+		return err
+	}
+
+	if s.maxNoteRevisions <= 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM note_revisions
+		WHERE note_id = $1 AND revision <= (SELECT max(revision) FROM note_revisions WHERE note_id = $1) - $2`,
+		noteID,
+		s.maxNoteRevisions,
+	)
+
+	return err
+}
+
+// GetNoteRevisions returns the kept revisions of the note, the latest first.
+func (s *Storage) GetNoteRevisions(ctx context.Context, noteID, userID int) ([]models.NoteRevision, error) {
+	const op = "storage.postgres.GetNoteRevisions"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var resRevisions []models.NoteRevision
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT r.revision, r.title, r.content, r.created_at
+		FROM note_revisions r
+		JOIN notes n ON n.id = r.note_id
+		WHERE r.note_id = $1 AND n.user_id = $2 AND n.deleted_at IS NULL
+		ORDER BY r.revision DESC`,
+		noteID,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision models.NoteRevision
+
+		if err := rows.Scan(&revision.Revision, &revision.Title, &revision.Content, &revision.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resRevisions = append(resRevisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// every note has at least the revision it was saved with
+	if len(resRevisions) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
+	}
+
+	return resRevisions, nil
+}
+
+func (s *Storage) GetNoteRevision(ctx context.Context, noteID, userID, revision int) (models.NoteRevision, error) {
+	const op = "storage.postgres.GetNoteRevision"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var resRevision models.NoteRevision
+
+	err := s.pool.QueryRow(
+		ctx,
+		`SELECT r.revision, r.title, r.content, r.created_at
+		FROM note_revisions r
+		JOIN notes n ON n.id = r.note_id
+		WHERE r.note_id = $1 AND n.user_id = $2 AND n.deleted_at IS NULL AND r.revision = $3`,
+		noteID,
+		userID,
+		revision,
+	).Scan(&resRevision.Revision, &resRevision.Title, &resRevision.Content, &resRevision.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.NoteRevision{}, fmt.Errorf("%s: %w", op, storage.ErrNoNoteRevision)
+	}
+	if err != nil {
+		return models.NoteRevision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resRevision, nil
+}
+
+// RestoreNoteRevision puts the title and content of an old revision back into the note,
+// which makes them its latest revision.
+func (s *Storage) RestoreNoteRevision(ctx context.Context, noteID, userID, revision int) (models.Note, error) {
+	const op = "storage.postgres.RestoreNoteRevision"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var title, content string
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(
+		ctx,
+		`SELECT r.title, r.content
+		FROM note_revisions r
+		JOIN notes n ON n.id = r.note_id
+		WHERE r.note_id = $1 AND n.user_id = $2 AND n.deleted_at IS NULL AND r.revision = $3
+		FOR UPDATE OF n`,
+		noteID,
+		userID,
+		revision,
+	).Scan(&title, &content)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Note{}, fmt.Errorf("%s: %w", op, storage.ErrNoNoteRevision)
+	}
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	note, err := scanNote(tx.QueryRow(
+		ctx,
+		`UPDATE notes
+		SET title = $1,
+			content = $2
+		WHERE id = $3
+		RETURNING `+noteColumns,
+		title,
+		content,
+		noteID,
+	))
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.saveNoteRevision(ctx, tx, note.ID); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, nil
+}
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"todo/internal/models"
+	"todo/internal/storage"
+	"unicode"
+)
+
+// searchConfig is the text search configuration of notes.search. It doesn't stem
+// or drop stop words, so it works the same for notes in any language.
+const searchConfig = "simple"
+
+// tsQuery turns a search query into to_tsquery syntax. Words are ANDed, "quoted words"
+// are a phrase, -word and -"phrase" exclude, word* matches a prefix and OR between
+// two terms matches either of them. Everything but letters and digits is dropped
+// from words, so the result is always a valid tsquery. It is empty if q has no words.
+func tsQuery(q string) string {
+	var b strings.Builder
+	or := false
+	rest := []rune(q)
+
+	for len(rest) > 0 {
+		if unicode.IsSpace(rest[0]) {
+			rest = rest[1:]
+
+			continue
+		}
+
+		negate := false
+		if rest[0] == '-' {
+			negate = true
+			rest = rest[1:]
+		}
+
+		var words []string
+		if len(rest) > 0 && rest[0] == '"' {
+			end := 1
+			for end < len(rest) && rest[end] != '"' {
+				end++
+			}
+
+			words = strings.Fields(string(rest[1:end]))
+			rest = rest[min(end+1, len(rest)):]
+		} else {
+			end := 0
+			for end < len(rest) && !unicode.IsSpace(rest[end]) {
+				end++
+			}
+
+			if word := string(rest[:end]); word == "OR" && !negate {
+				or = b.Len() > 0
+				rest = rest[end:]
+
+				continue
+			}
+
+			words = []string{string(rest[:end])}
+			rest = rest[end:]
+		}
+
+		term := phrase(words)
+		if term == "" {
+			continue
+		}
+		if negate {
+			term = "!(" + term + ")"
+		}
+
+		switch {
+		case b.Len() == 0:
+		case or:
+			b.WriteString(" | ")
+		default:
+			b.WriteString(" & ")
+		}
+		or = false
+
+		b.WriteString(term)
+	}
+
+	return b.String()
+}
+
+// phrase joins the lexemes of words with the followed-by operator.
+func phrase(words []string) string {
+	lexemes := make([]string, 0, len(words))
+
+	for _, word := range words {
+		prefix := strings.HasSuffix(word, "*")
+
+		parts := strings.FieldsFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(parts) == 0 {
+			continue
+		}
+		if prefix {
+			parts[len(parts)-1] += ":*"
+		}
+
+		lexemes = append(lexemes, parts...)
+	}
+
+	return strings.Join(lexemes, " <-> ")
+}
+
+// SearchNotes returns the user's notes matching the query, best matches first.
+// See tsQuery for the query syntax.
+func (s *Storage) SearchNotes(ctx context.Context, userID int, query string, limit, offset int) ([]models.NoteSearchResult, error) {
+	const op = "storage.postgres.SearchNotes"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resResults := make([]models.NoteSearchResult, 0, limit)
+
+	tsq := tsQuery(query)
+	if tsq == "" {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrEmptySearchQuery)
+	}
+
+	// the text is html escaped before ts_headline, so only the <mark> tags are markup
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT `+noteColumns+`,
+			ts_rank_cd(search, q),
+			ts_headline($2::regconfig, `+escapeHTML("title")+`, q, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+			ts_headline($2::regconfig, `+escapeHTML("content")+`, q, 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2')
+		FROM notes, to_tsquery($2::regconfig, $3) q
+		WHERE user_id = $1 AND deleted_at IS NULL AND search @@ q
+		ORDER BY ts_rank_cd(search, q) DESC, id DESC
+		LIMIT $4
+		OFFSET $5`,
+		userID,
+		searchConfig,
+		tsq,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.NoteSearchResult
+
+		if err := rows.Scan(
+			&result.Note.ID,
+			&result.Note.Title,
+			&result.Note.Content,
+			&result.Note.Status,
+			&result.Note.Priority,
+			&result.Note.DueAt,
+			&result.Note.CompletedAt,
+			&result.Note.Tags,
+			&result.Note.ProjectID,
+			&result.Note.RRule,
+			&result.Note.Timezone,
+			&result.Note.ItemsDone,
+			&result.Note.ItemsTotal,
+			&result.Note.CreatedAt,
+			&result.Note.UpdatedAt,
+			&result.Note.DeletedAt,
+			&result.Note.Version,
+			&result.Rank,
+			&result.HighlightedTitle,
+			&result.Snippet,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resResults = append(resResults, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resResults, nil
+}
+
+func escapeHTML(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`, column)
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"slices"
+	"strings"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+func (s *Storage) SaveTag(ctx context.Context, userID int, name string) (int64, error) {
+	const op = "storage.postgres.SaveTag"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+	var pgErr *pgconn.PgError
+
+	err := s.pool.QueryRow(
+		ctx,
+		`INSERT INTO tags (user_id, name)
+		VALUES ($1, $2)
+		RETURNING id`,
+		userID,
+		strings.TrimSpace(name),
+	).Scan(&id)
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrTagExist)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetTags(ctx context.Context, userID int) ([]models.Tag, error) {
+	const op = "storage.postgres.GetTags"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resTags := make([]models.Tag, 0)
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT id, name, created_at
+		FROM tags
+		WHERE user_id = $1
+		ORDER BY name`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag models.Tag
+
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resTags = append(resTags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resTags, nil
+}
+
+// UpdateTag renames the tag on every note that has it.
+func (s *Storage) UpdateTag(ctx context.Context, tagID, userID int, name string) (int64, error) {
+	const op = "storage.postgres.UpdateTag"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+	var pgErr *pgconn.PgError
+
+	err := s.pool.QueryRow(
+		ctx,
+		`UPDATE tags
+		SET name = $1
+		WHERE id = $2 AND user_id = $3
+		RETURNING id`,
+		strings.TrimSpace(name),
+		tagID,
+		userID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoTag)
+	}
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrTagExist)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// DeleteTag deletes the tag and takes it off every note.
+func (s *Storage) DeleteTag(ctx context.Context, tagID, userID int) (int64, error) {
+	const op = "storage.postgres.DeleteTag"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	err := s.pool.QueryRow(
+		ctx,
+		`DELETE FROM tags
+		WHERE id = $1 AND user_id = $2
+		RETURNING id`,
+		tagID,
+		userID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoTag)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// normalizeTags trims tag names and drops empty and duplicate ones.
+func normalizeTags(tags []string) []string {
+	resTags := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(resTags, tag) {
+			resTags = append(resTags, tag)
+		}
+	}
+
+	return resTags
+}
+
+// setNoteTags replaces the tags of the note, creating the tags the user doesn't have yet.
+func setNoteTags(ctx context.Context, tx pgx.Tx, userID int, noteID int64, tags []string) error {
+	tags = normalizeTags(tags)
+
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM note_tags
+		WHERE note_id = $1`,
+		noteID,
+	); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO tags (user_id, name)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT (user_id, name) DO NOTHING`,
+		userID,
+		tags,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`INSERT INTO note_tags (note_id, tag_id)
+		SELECT $1, id
+		FROM tags
+		WHERE user_id = $2 AND name = ANY($3)`,
+		noteID,
+		userID,
+		tags,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
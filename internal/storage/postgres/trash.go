@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"todo/internal/models"
+	"todo/internal/storage"
+)
+
+// GetTrash returns the user's deleted notes, the most recently deleted first.
+func (s *Storage) GetTrash(ctx context.Context, userID, limit, offset int) ([]models.Note, error) {
+	const op = "storage.postgres.GetTrash"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	resNotes := make([]models.Note, 0, limit)
+
+	rows, err := s.pool.Query(
+		ctx,
+		`SELECT `+noteColumns+`
+		FROM notes
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2
+		OFFSET $3`,
+		userID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		resNotes = append(resNotes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resNotes, nil
+}
+
+// RestoreNote takes the note out of the trash.
+func (s *Storage) RestoreNote(ctx context.Context, noteID, userID int) (models.Note, error) {
+	const op = "storage.postgres.RestoreNote"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	note, err := scanNote(s.pool.QueryRow(
+		ctx,
+		`UPDATE notes
+		SET deleted_at = NULL
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+		RETURNING `+noteColumns,
+		noteID,
+		userID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Note{}, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
+	}
+	if err != nil {
+		return models.Note{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, nil
+}
+
+// PurgeNote deletes a note in the trash for good.
+func (s *Storage) PurgeNote(ctx context.Context, noteID, userID int) (int64, error) {
+	const op = "storage.postgres.PurgeNote"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+	var id int64
+
+	err := s.pool.QueryRow(
+		ctx,
+		`DELETE FROM notes
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+		RETURNING id`,
+		noteID,
+		userID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrNoNotes)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// PurgeDeletedNotes deletes for good the notes that went to the trash before deletedBefore.
+func (s *Storage) PurgeDeletedNotes(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	const op = "storage.postgres.PurgeDeletedNotes"
+	ctx, cancel := context.WithTimeout(ctx, s.standardTimeout)
+	defer cancel()
+
+	tag, err := s.pool.Exec(
+		ctx,
+		`DELETE FROM notes
+		WHERE deleted_at < $1`,
+		deletedBefore,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
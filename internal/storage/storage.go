@@ -5,7 +5,49 @@ import (
 )
 
 var (
-	ErrUserExist   = errors.New("user with this id already exists")
-	ErrUserNoNotes = errors.New("no notes with this user_id and query parameters")
-	ErrNoNotes     = errors.New("no notes with this id")
+	ErrUserExist    = errors.New("user with this id already exists")
+	ErrEmailExist   = errors.New("user with this email already exists")
+	ErrUserNotFound = errors.New("no such user")
+	ErrNoNotes      = errors.New("no notes with this id")
+
+	ErrNoNoteRevision      = errors.New("no note revision with this number")
+	ErrNoteVersionMismatch = errors.New("note version doesn't match")
+	ErrBatchAborted        = errors.New("batch was rolled back because of another operation")
+
+	ErrNoNoteItem        = errors.New("no note items with this id")
+	ErrInvalidItemsOrder = errors.New("items order doesn't list every item of the note once")
+
+	ErrNoReminder     = errors.New("no reminders with this id")
+	ErrNoDueAt        = errors.New("note has no due date")
+	ErrNoNotification = errors.New("no notifications with this id")
+
+	ErrEmptySearchQuery = errors.New("search query has no words")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSort      = errors.New("invalid sort")
+
+	ErrNoProject       = errors.New("no projects with this id")
+	ErrNoParentProject = errors.New("no parent project with this id")
+	ErrProjectCycle    = errors.New("project can't be nested in itself")
+
+	ErrTagExist = errors.New("tag with this name already exists")
+	ErrNoTag    = errors.New("no tags with this id")
+
+	ErrRefreshSessionNotFound = errors.New("no active refresh session with this token")
+	ErrRefreshSessionExpired  = errors.New("refresh session is expired")
+	ErrRefreshTokenReused     = errors.New("refresh token was already used")
+
+	ErrNoPersonalAccessToken = errors.New("no active personal access token with this id or token")
+	ErrNoCalendarToken       = errors.New("no calendar token")
+
+	ErrTOTPAlreadyEnabled = errors.New("totp is already enabled")
+	ErrTOTPNotEnrolled    = errors.New("totp is not enrolled")
+	ErrTOTPStepUsed       = errors.New("totp code was already used")
+	ErrNoRecoveryCode     = errors.New("no unused recovery code")
+
+	ErrNoEmailToken      = errors.New("no unused email token")
+	ErrEmailTokenExpired = errors.New("email token is expired")
+
+	ErrNoOIDCState = errors.New("no unexpired oidc login state")
+
+	ErrNoAuthLockout = errors.New("no failed authentication attempts")
 )
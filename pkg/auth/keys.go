@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey       = errors.New("no key to sign tokens with")
+	ErrUnknownKeyID       = errors.New("unknown key id")
+	ErrUnsupportedAlg     = errors.New("unsupported signing algorithm")
+	ErrKeyIDEmpty         = errors.New("key id is empty")
+	ErrKeyIDDuplicate     = errors.New("duplicate key id")
+	ErrKeyFileNotProvided = errors.New("neither private nor public key file is provided")
+)
+
+// KeyConfig describes one signing key. A key signs new tokens from SignFrom
+// until a key with a later SignFrom takes over, and verifies tokens until RetireAt.
+// Keys with only PublicKeyPath are used for verification only.
+// Zero SignFrom means "since always", zero RetireAt means "never".
+type KeyConfig struct {
+	ID             string
+	Algorithm      string
+	PrivateKeyPath string
+	PublicKeyPath  string
+	SignFrom       time.Time
+	RetireAt       time.Time
+}
+
+type key struct {
+	id         string
+	method     jwt.SigningMethod
+	signingKey any
+	verifyKey  any
+	signFrom   time.Time
+	retireAt   time.Time
+}
+
+func (k key) canSign(now time.Time) bool {
+	return k.signingKey != nil && !k.signFrom.After(now) && k.canVerify(now)
+}
+
+func (k key) canVerify(now time.Time) bool {
+	return k.retireAt.IsZero() || now.Before(k.retireAt)
+}
+
+func loadKey(cfg KeyConfig) (key, error) {
+	const op = "auth.loadKey"
+	k := key{id: cfg.ID, signFrom: cfg.SignFrom, retireAt: cfg.RetireAt}
+
+	if cfg.ID == "" {
+		return key{}, fmt.Errorf("%s: %w", op, ErrKeyIDEmpty)
+	}
+	if cfg.PrivateKeyPath == "" && cfg.PublicKeyPath == "" {
+		return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, ErrKeyFileNotProvided)
+	}
+
+	switch cfg.Algorithm {
+	case AlgRS256:
+		k.method = jwt.SigningMethodRS256
+
+		if cfg.PrivateKeyPath != "" {
+			pem, err := os.ReadFile(cfg.PrivateKeyPath)
+			if err != nil {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, err)
+			}
+
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, err)
+			}
+
+			k.signingKey, k.verifyKey = privateKey, &privateKey.PublicKey
+		} else {
+			pem, err := os.ReadFile(cfg.PublicKeyPath)
+			if err != nil {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, err)
+			}
+
+			k.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, err)
+			}
+		}
+	case AlgEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+
+		if cfg.PrivateKeyPath != "" {
+			pem, err := os.ReadFile(cfg.PrivateKeyPath)
+			if err != nil {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, err)
+			}
+
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, err)
+			}
+
+			edPrivateKey, ok := privateKey.(ed25519.PrivateKey)
+			if !ok {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, jwt.ErrNotEdPrivateKey)
+			}
+
+			k.signingKey, k.verifyKey = edPrivateKey, edPrivateKey.Public()
+		} else {
+			pem, err := os.ReadFile(cfg.PublicKeyPath)
+			if err != nil {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, err)
+			}
+
+			k.verifyKey, err = jwt.ParseEdPublicKeyFromPEM(pem)
+			if err != nil {
+				return key{}, fmt.Errorf("%s: key %s: %w", op, cfg.ID, err)
+			}
+		}
+	default:
+		return key{}, fmt.Errorf("%s: key %s: %w: %q", op, cfg.ID, ErrUnsupportedAlg, cfg.Algorithm)
+	}
+
+	return k, nil
+}
+
+func loadKeys(cfgs []KeyConfig) ([]key, error) {
+	const op = "auth.loadKeys"
+	keys := make([]key, 0, len(cfgs))
+	ids := make(map[string]struct{}, len(cfgs))
+
+	for _, cfg := range cfgs {
+		if _, ok := ids[cfg.ID]; ok {
+			return nil, fmt.Errorf("%s: %w: %s", op, ErrKeyIDDuplicate, cfg.ID)
+		}
+		ids[cfg.ID] = struct{}{}
+
+		k, err := loadKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		keys = append(keys, k)
+	}
+
+	// the latest key that is allowed to sign comes first
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].signFrom.After(keys[j].signFrom)
+	})
+
+	return keys, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that verify tokens right now, including keys
+// that are scheduled to start signing later, so that verifiers can fetch them in advance.
+func (m *Manager) JWKS() JWKS {
+	now := time.Now()
+	jwks := JWKS{Keys: make([]JWK, 0, len(m.keys))}
+
+	for _, k := range m.keys {
+		if !k.canVerify(now) {
+			continue
+		}
+
+		switch verifyKey := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: k.method.Alg(),
+				Kid: k.id,
+				N:   base64.RawURLEncoding.EncodeToString(verifyKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(verifyKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: k.method.Alg(),
+				Kid: k.id,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(verifyKey),
+			})
+		}
+	}
+
+	return jwks
+}
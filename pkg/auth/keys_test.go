@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// keyFiles holds the PEM files of a generated key pair.
+type keyFiles struct {
+	private, public string
+	publicKey       any
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func writeKeyFiles(t *testing.T, name string, privateKey, publicKey any) keyFiles {
+	t.Helper()
+	dir := t.TempDir()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+
+	files := keyFiles{
+		private:   filepath.Join(dir, name+".pem"),
+		public:    filepath.Join(dir, name+".pub.pem"),
+		publicKey: publicKey,
+	}
+	writePEM(t, files.private, "PRIVATE KEY", privateDER)
+	writePEM(t, files.public, "PUBLIC KEY", publicDER)
+
+	return files
+}
+
+func rsaKeyFiles(t *testing.T) keyFiles {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+
+	return writeKeyFiles(t, "rsa", privateKey, &privateKey.PublicKey)
+}
+
+func ed25519KeyFiles(t *testing.T) keyFiles {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+
+	return writeKeyFiles(t, "ed25519", privateKey, publicKey)
+}
+
+func TestLoadKeys(t *testing.T) {
+	rsaFiles, edFiles := rsaKeyFiles(t), ed25519KeyFiles(t)
+
+	tests := []struct {
+		name        string
+		cfgs        []KeyConfig
+		wantErr     error
+		wantCanSign bool
+	}{
+		{
+			name:        "rsa private key",
+			cfgs:        []KeyConfig{{ID: "k1", Algorithm: AlgRS256, PrivateKeyPath: rsaFiles.private}},
+			wantCanSign: true,
+		},
+		{
+			name: "rsa public key",
+			cfgs: []KeyConfig{{ID: "k1", Algorithm: AlgRS256, PublicKeyPath: rsaFiles.public}},
+		},
+		{
+			name:        "ed25519 private key",
+			cfgs:        []KeyConfig{{ID: "k1", Algorithm: AlgEdDSA, PrivateKeyPath: edFiles.private}},
+			wantCanSign: true,
+		},
+		{
+			name: "ed25519 public key",
+			cfgs: []KeyConfig{{ID: "k1", Algorithm: AlgEdDSA, PublicKeyPath: edFiles.public}},
+		},
+		{
+			name:    "empty id",
+			cfgs:    []KeyConfig{{Algorithm: AlgRS256, PrivateKeyPath: rsaFiles.private}},
+			wantErr: ErrKeyIDEmpty,
+		},
+		{
+			name:    "no key file",
+			cfgs:    []KeyConfig{{ID: "k1", Algorithm: AlgRS256}},
+			wantErr: ErrKeyFileNotProvided,
+		},
+		{
+			name:    "missing key file",
+			cfgs:    []KeyConfig{{ID: "k1", Algorithm: AlgRS256, PrivateKeyPath: filepath.Join(t.TempDir(), "missing.pem")}},
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name:    "unsupported algorithm",
+			cfgs:    []KeyConfig{{ID: "k1", Algorithm: "HS256", PrivateKeyPath: rsaFiles.private}},
+			wantErr: ErrUnsupportedAlg,
+		},
+		{
+			name: "duplicate id",
+			cfgs: []KeyConfig{
+				{ID: "k1", Algorithm: AlgRS256, PrivateKeyPath: rsaFiles.private},
+				{ID: "k1", Algorithm: AlgEdDSA, PrivateKeyPath: edFiles.private},
+			},
+			wantErr: ErrKeyIDDuplicate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := loadKeys(tt.cfgs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loadKeys() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := keys[0].canSign(time.Now()); got != tt.wantCanSign {
+				t.Errorf("canSign() = %v, want %v", got, tt.wantCanSign)
+			}
+			if !keys[0].canVerify(time.Now()) {
+				t.Errorf("canVerify() = false, want true")
+			}
+		})
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	files := rsaKeyFiles(t)
+	now := time.Now()
+
+	m, err := NewManager([]KeyConfig{
+		{ID: "current", Algorithm: AlgRS256, PrivateKeyPath: files.private},
+		{ID: "next", Algorithm: AlgRS256, PrivateKeyPath: files.private, SignFrom: now.Add(time.Hour)},
+		{ID: "retired", Algorithm: AlgRS256, PrivateKeyPath: files.private, SignFrom: now.Add(-time.Hour), RetireAt: now},
+		{ID: "public", Algorithm: AlgRS256, PublicKeyPath: files.public, SignFrom: now.Add(-time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{name: "before the rotation", at: now, want: "current"},
+		{name: "after the rotation", at: now.Add(2 * time.Hour), want: "next"},
+		{name: "before the retirement", at: now.Add(-time.Minute), want: "retired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, ok := m.signingKey(tt.at)
+			if !ok || k.id != tt.want {
+				t.Errorf("signingKey() = %q, %v, want %q", k.id, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := m.verificationKey("retired", now); ok {
+		t.Errorf("verificationKey() of a retired key = true, want false")
+	}
+	if _, ok := m.verificationKey("next", now); !ok {
+		t.Errorf("verificationKey() of a key that signs later = false, want true")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	oldFiles, newFiles := rsaKeyFiles(t), ed25519KeyFiles(t)
+	now := time.Now()
+
+	before, err := NewManager([]KeyConfig{
+		{ID: "old", Algorithm: AlgRS256, PrivateKeyPath: oldFiles.private},
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	oldToken, err := before.GenerateAccessToken(1, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	t.Run("old key still verifies", func(t *testing.T) {
+		during, err := NewManager([]KeyConfig{
+			{ID: "old", Algorithm: AlgRS256, PrivateKeyPath: oldFiles.private},
+			{ID: "new", Algorithm: AlgEdDSA, PrivateKeyPath: newFiles.private, SignFrom: now.Add(-time.Minute)},
+		})
+		if err != nil {
+			t.Fatalf("NewManager() error = %v", err)
+		}
+
+		claims, err := during.ParseToken(oldToken)
+		if err != nil {
+			t.Fatalf("ParseToken() of a token signed with the old key error = %v", err)
+		}
+		if claims.UserID != 1 {
+			t.Errorf("ParseToken() UserID = %d, want 1", claims.UserID)
+		}
+
+		newToken, err := during.GenerateAccessToken(2, time.Hour)
+		if err != nil {
+			t.Fatalf("GenerateAccessToken() error = %v", err)
+		}
+		if k, _ := during.signingKey(time.Now()); k.id != "new" {
+			t.Errorf("signingKey() = %q, want %q", k.id, "new")
+		}
+		if _, err := during.ParseToken(newToken); err != nil {
+			t.Errorf("ParseToken() of a token signed with the new key error = %v", err)
+		}
+	})
+
+	t.Run("retired key is rejected", func(t *testing.T) {
+		after, err := NewManager([]KeyConfig{
+			{ID: "old", Algorithm: AlgRS256, PrivateKeyPath: oldFiles.private, RetireAt: now.Add(-time.Second)},
+			{ID: "new", Algorithm: AlgEdDSA, PrivateKeyPath: newFiles.private, SignFrom: now.Add(-time.Minute)},
+		})
+		if err != nil {
+			t.Fatalf("NewManager() error = %v", err)
+		}
+
+		if _, err := after.ParseToken(oldToken); !errors.Is(err, ErrUnknownKeyID) {
+			t.Errorf("ParseToken() error = %v, want %v", err, ErrUnknownKeyID)
+		}
+	})
+}
+
+func TestJWKS(t *testing.T) {
+	t.Setenv("JWT_SECRET", "legacy secret")
+	rsaFiles, edFiles := rsaKeyFiles(t), ed25519KeyFiles(t)
+	now := time.Now()
+
+	m, err := NewManager([]KeyConfig{
+		{ID: "rsa", Algorithm: AlgRS256, PrivateKeyPath: rsaFiles.private},
+		{ID: "ed25519", Algorithm: AlgEdDSA, PublicKeyPath: edFiles.public, SignFrom: now.Add(time.Hour)},
+		{ID: "retired", Algorithm: AlgRS256, PublicKeyPath: rsaFiles.public, RetireAt: now.Add(-time.Second)},
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	rsaKey := rsaFiles.publicKey.(*rsa.PublicKey)
+	want := map[string]JWK{
+		"rsa": {
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: "rsa",
+			N:   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		"ed25519": {
+			Kty: "OKP",
+			Use: "sig",
+			Alg: "EdDSA",
+			Kid: "ed25519",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(edFiles.publicKey.(ed25519.PublicKey)),
+		},
+	}
+
+	// the HS256 secret and the retired key must not be published
+	jwks := m.JWKS()
+	if len(jwks.Keys) != len(want) {
+		t.Fatalf("JWKS() has %d keys, want %d: %+v", len(jwks.Keys), len(want), jwks.Keys)
+	}
+
+	for _, got := range jwks.Keys {
+		if got != want[got.Kid] {
+			t.Errorf("JWKS() key = %+v, want %+v", got, want[got.Kid])
+		}
+	}
+
+	if e := want["rsa"].E; e != "AQAB" {
+		t.Errorf("JWKS() e = %q, want %q", e, "AQAB")
+	}
+}
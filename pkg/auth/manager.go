@@ -1,50 +1,121 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 )
 
-// TODO: добавить refresh token и эндпоинт, где пользователи могут обновлять access token
-
-const alg = "HS256"
+const (
+	tokenIDBytes     = 16
+	opaqueTokenBytes = 32
+)
 
 var (
-	ErrSubEmpty    = errors.New("subject is empty")
-	ErrSecretEmpty = errors.New("secret is empty")
+	ErrSubEmpty      = errors.New("subject is empty")
+	ErrTokenIDEmpty  = errors.New("token id is empty")
+	ErrIssuedAtEmpty = errors.New("issued at is empty")
+
+	ErrWrongTokenPurpose = errors.New("token is not meant for this use")
 )
 
+const (
+	purposeAccess = ""
+	purposeMFA    = "mfa_pending"
+)
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// Claims are the claims of a validated access token.
+// For personal access tokens PersonalAccessTokenID and Scopes are set instead of TokenID.
+// Role isn't part of the token, it is filled in from the user record on every request,
+// so a role change applies at once.
+type Claims struct {
+	UserID                int
+	TokenID               string
+	PersonalAccessTokenID int64
+	Scopes                []string
+	Role                  string
+	IssuedAt              time.Time
+	ExpiresAt             time.Time
+}
+
 type Manager struct {
-	jwtSecret []byte
+	keys []key
+	algs []string
 }
 
-func NewManager() (*Manager, error) {
+// NewManager loads the signing keys. The HS256 secret from JWT_SECRET is kept
+// for tokens without a kid header: it signs tokens only if no other keys are configured,
+// otherwise it just verifies tokens issued before the switch until they expire.
+func NewManager(keyCfgs []KeyConfig) (*Manager, error) {
 	const op = "auth.NewManager"
 	jwtSecret := os.Getenv("JWT_SECRET")
 
-	if jwtSecret == "" {
-		return nil, fmt.Errorf("%s: %w", op, ErrSecretEmpty)
+	keys, err := loadKeys(keyCfgs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &Manager{[]byte(jwtSecret)}, nil
+	if jwtSecret != "" {
+		legacyKey := key{method: jwt.SigningMethodHS256, verifyKey: []byte(jwtSecret)}
+		if len(keys) == 0 {
+			legacyKey.signingKey = []byte(jwtSecret)
+		}
+
+		keys = append(keys, legacyKey)
+	}
+
+	m := &Manager{keys: keys}
+
+	if _, ok := m.signingKey(time.Now()); !ok {
+		return nil, fmt.Errorf("%s: %w", op, ErrNoSigningKey)
+	}
+
+	for _, k := range keys {
+		if !slices.Contains(m.algs, k.method.Alg()) {
+			m.algs = append(m.algs, k.method.Alg())
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Manager) signingKey(now time.Time) (key, bool) {
+	for _, k := range m.keys {
+		if k.canSign(now) {
+			return k, true
+		}
+	}
+
+	return key{}, false
+}
+
+func (m *Manager) verificationKey(id string, now time.Time) (key, bool) {
+	for _, k := range m.keys {
+		if k.id == id && k.canVerify(now) {
+			return k, true
+		}
+	}
+
+	return key{}, false
 }
 
 func (m *Manager) GenerateAccessToken(userID int, tokenTTL time.Duration) (string, error) {
 	const op = "auth.GenerateAccessToken"
 
-	generatedJWT := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
-		jwt.RegisteredClaims{
-			Subject:   strconv.Itoa(userID),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenTTL)),
-		},
-	)
-
-	rawJWT, err := generatedJWT.SignedString(m.jwtSecret)
+	rawJWT, err := m.generateToken(userID, tokenTTL, purposeAccess)
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
@@ -52,35 +123,166 @@ func (m *Manager) GenerateAccessToken(userID int, tokenTTL time.Duration) (strin
 	return rawJWT, nil
 }
 
-func (m *Manager) ParseToken(receivedJWT string) (int, error) {
+func (m *Manager) ParseToken(receivedJWT string) (Claims, error) {
 	const op = "auth.ParseToken"
-	parsedJWT, err := jwt.Parse(
+
+	claims, err := m.parseToken(receivedJWT, purposeAccess)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return claims, nil
+}
+
+// GenerateMFAToken returns a token proving that the user passed the password check
+// but still has to enter a second factor. ParseToken doesn't accept it.
+func (m *Manager) GenerateMFAToken(userID int, tokenTTL time.Duration) (string, error) {
+	const op = "auth.GenerateMFAToken"
+
+	rawJWT, err := m.generateToken(userID, tokenTTL, purposeMFA)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rawJWT, nil
+}
+
+func (m *Manager) ParseMFAToken(receivedJWT string) (Claims, error) {
+	const op = "auth.ParseMFAToken"
+
+	claims, err := m.parseToken(receivedJWT, purposeMFA)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return claims, nil
+}
+
+func (m *Manager) generateToken(userID int, tokenTTL time.Duration, purpose string) (string, error) {
+	now := time.Now()
+
+	signingKey, ok := m.signingKey(now)
+	if !ok {
+		return "", ErrNoSigningKey
+	}
+
+	tokenID, err := randomToken(tokenIDBytes)
+	if err != nil {
+		return "", err
+	}
+
+	generatedJWT := jwt.NewWithClaims(
+		signingKey.method,
+		tokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        tokenID,
+				Subject:   strconv.Itoa(userID),
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+			},
+			Purpose: purpose,
+		},
+	)
+
+	if signingKey.id != "" {
+		generatedJWT.Header["kid"] = signingKey.id
+	}
+
+	return generatedJWT.SignedString(signingKey.signingKey)
+}
+
+func (m *Manager) parseToken(receivedJWT, purpose string) (Claims, error) {
+	var parsedClaims tokenClaims
+
+	_, err := jwt.ParseWithClaims(
 		receivedJWT,
+		&parsedClaims,
 		func(token *jwt.Token) (any, error) {
-			if token.Method != jwt.SigningMethodHS256 {
+			kid, _ := token.Header["kid"].(string)
+
+			verificationKey, ok := m.verificationKey(kid, time.Now())
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+			}
+
+			if token.Method.Alg() != verificationKey.method.Alg() {
 				return nil, fmt.Errorf("unexpected alg type: %s", token.Header["alg"])
 			}
 
-			return m.jwtSecret, nil
+			return verificationKey.verifyKey, nil
 		},
-		jwt.WithValidMethods([]string{alg}),
+		jwt.WithValidMethods(m.algs),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
 	)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return Claims{}, err
+	}
+
+	if parsedClaims.Purpose != purpose {
+		return Claims{}, ErrWrongTokenPurpose
+	}
+	if parsedClaims.Subject == "" {
+		return Claims{}, ErrSubEmpty
+	}
+	if parsedClaims.ID == "" {
+		return Claims{}, ErrTokenIDEmpty
+	}
+	if parsedClaims.IssuedAt == nil {
+		return Claims{}, ErrIssuedAtEmpty
 	}
 
-	sub, err := parsedJWT.Claims.GetSubject()
+	userID, err := strconv.Atoi(parsedClaims.Subject)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return Claims{}, err
 	}
-	if sub == "" {
-		return 0, fmt.Errorf("%s: %w", op, ErrSubEmpty)
+
+	return Claims{
+		UserID:    userID,
+		TokenID:   parsedClaims.ID,
+		IssuedAt:  parsedClaims.IssuedAt.Time,
+		ExpiresAt: parsedClaims.ExpiresAt.Time,
+	}, nil
+}
+
+// GenerateRefreshToken returns an opaque refresh token and its hash.
+// Only the hash should be stored.
+func (m *Manager) GenerateRefreshToken() (string, string, error) {
+	const op = "auth.GenerateRefreshToken"
+
+	token, err := randomToken(opaqueTokenBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	userID, err := strconv.Atoi(sub)
+	return token, m.HashToken(token), nil
+}
+
+// GenerateOpaqueToken returns a random single-purpose token, e.g. for email links,
+// and its hash. Only the hash should be stored.
+func (m *Manager) GenerateOpaqueToken() (string, string, error) {
+	const op = "auth.GenerateOpaqueToken"
+
+	token, err := randomToken(opaqueTokenBytes)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, m.HashToken(token), nil
+}
+
+func (m *Manager) HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	return userID, nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passwordHashCost = 12
+
+// MaxPasswordBytes is the most bcrypt hashes, the validator's max counts runes
+// and lets longer passwords through.
+const MaxPasswordBytes = 72
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrPasswordTooLong    = errors.New("password is longer than 72 bytes")
+)
+
+// dummyPasswordHash is compared against when the user doesn't exist,
+// so that a missing username takes as long to reject as a wrong password.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), passwordHashCost)
+
+func (m *Manager) HashPassword(password string) (string, error) {
+	const op = "auth.HashPassword"
+
+	if len(password) > MaxPasswordBytes {
+		return "", fmt.Errorf("%s: %w", op, ErrPasswordTooLong)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordHashCost)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return string(hash), nil
+}
+
+// ComparePassword returns ErrInvalidCredentials if password doesn't match hash.
+// An empty hash is treated as an unknown user.
+func (m *Manager) ComparePassword(hash, password string) error {
+	const op = "auth.ComparePassword"
+
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
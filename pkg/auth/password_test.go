@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashPasswordLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{name: "72 bytes", password: strings.Repeat("a", 72)},
+		{name: "73 bytes", password: strings.Repeat("a", 73), wantErr: ErrPasswordTooLong},
+		{name: "40 runes of 2 bytes", password: strings.Repeat("ü", 40), wantErr: ErrPasswordTooLong},
+	}
+
+	m := &Manager{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := m.HashPassword(tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("HashPassword() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if err := m.ComparePassword(hash, tt.password); err != nil {
+				t.Errorf("ComparePassword() error = %v", err)
+			}
+		})
+	}
+}
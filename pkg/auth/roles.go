@@ -0,0 +1,11 @@
+package auth
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsAdmin reports whether the token may use admin rights. Only session tokens of admins can.
+func (c Claims) IsAdmin() bool {
+	return c.Role == RoleAdmin && c.HasScope(ScopeAdmin)
+}
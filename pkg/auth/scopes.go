@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+	// ScopeTokens, ScopeSessions, ScopeAccount and ScopeAdmin are only held by session tokens,
+	// so a personal access token can't mint or revoke other tokens, change the account
+	// or use the admin rights of its owner.
+	ScopeTokens   = "tokens"
+	ScopeSessions = "sessions"
+	ScopeAccount  = "account"
+	ScopeAdmin    = "admin"
+)
+
+const personalAccessTokenPrefix = "todo_pat_"
+
+// HasScope reports whether the token grants scope. Session tokens have
+// no scope list and grant every scope.
+func (c Claims) HasScope(scope string) bool {
+	return c.Scopes == nil || slices.Contains(c.Scopes, scope)
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, personalAccessTokenPrefix)
+}
+
+// GeneratePersonalAccessToken returns a personal access token and its hash.
+// Only the hash should be stored.
+func (m *Manager) GeneratePersonalAccessToken() (string, string, error) {
+	const op = "auth.GeneratePersonalAccessToken"
+
+	token, err := randomToken(opaqueTokenBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	token = personalAccessTokenPrefix + token
+
+	return token, m.HashToken(token), nil
+}
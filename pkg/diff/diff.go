@@ -0,0 +1,180 @@
+// Package diff compares texts line by line with the linear space variant of
+// the Myers algorithm, which finds the shortest edit script.
+package diff
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// MaxLines caps the lines of both texts together, the work grows with their
+// number times the number of edits.
+const MaxLines = 5000
+
+var ErrTooLarge = errors.New("texts are too large to compare")
+
+// Line is a line of the edit script that turns the old text into the new one.
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns the edit script from a to b, deletions go before insertions where
+// a line is replaced. It returns ErrTooLarge when the texts have more than MaxLines
+// lines together.
+func Lines(a, b string) ([]Line, error) {
+	x, y := split(a), split(b)
+	if len(x)+len(y) > MaxLines {
+		return nil, ErrTooLarge
+	}
+
+	var lines []Line
+	edits(&lines, x, y)
+	reorder(lines)
+
+	return lines, nil
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+// edits appends the script from a to b, it splits both texts at the middle snake
+// and recurses into the halves before and after it.
+func edits(lines *[]Line, a, b []string) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, text := range a[:prefix] {
+		*lines = append(*lines, Line{Op: OpEqual, Text: text})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		for _, text := range midB {
+			*lines = append(*lines, Line{Op: OpInsert, Text: text})
+		}
+	case len(midB) == 0:
+		for _, text := range midA {
+			*lines = append(*lines, Line{Op: OpDelete, Text: text})
+		}
+	default:
+		// both halves are strictly smaller: without a common prefix or suffix
+		// the script has at least two edits
+		x, y, u, v := middleSnake(midA, midB)
+		edits(lines, midA[:x], midB[:y])
+		for _, text := range midA[x:u] {
+			*lines = append(*lines, Line{Op: OpEqual, Text: text})
+		}
+		edits(lines, midA[u:], midB[v:])
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		*lines = append(*lines, Line{Op: OpEqual, Text: text})
+	}
+}
+
+// middleSnake runs the search from both ends at once until the paths overlap and
+// returns the snake from (x, y) to (u, v) where they met. Only the furthest x of the
+// current round is kept per diagonal, so the memory is linear in the texts.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+
+	// forward[offset+k] is the furthest x reached from the start on diagonal k = x - y,
+	// backward[offset+k] the same from the end, counted on the reversed texts
+	forward := make([]int, 2*limit+3)
+	backward := make([]int, 2*limit+3)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			x := forward[offset+k-1] + 1
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			}
+
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			forward[offset+k] = x
+
+			if odd && k >= delta-(d-1) && k <= delta+(d-1) && x+backward[offset+delta-k] >= n {
+				return startX, startY, x, y
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			x := backward[offset+k-1] + 1
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			}
+
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+
+			backward[offset+k] = x
+
+			if !odd && k >= delta-d && k <= delta+d && x+forward[offset+delta-k] >= n {
+				return n - x, m - y, n - startX, m - startY
+			}
+		}
+	}
+
+	// unreachable: the paths overlap by the time d reaches half of n + m
+	return 0, 0, 0, 0
+}
+
+// reorder moves deletions before insertions within each run of changed lines.
+func reorder(lines []Line) {
+	for start := 0; start < len(lines); {
+		if lines[start].Op == OpEqual {
+			start++
+
+			continue
+		}
+
+		end := start
+		var deleted, inserted []Line
+		for end < len(lines) && lines[end].Op != OpEqual {
+			if lines[end].Op == OpDelete {
+				deleted = append(deleted, lines[end])
+			} else {
+				inserted = append(inserted, lines[end])
+			}
+			end++
+		}
+
+		copy(lines[start:], deleted)
+		copy(lines[start+len(deleted):], inserted)
+		start = end
+	}
+}
@@ -0,0 +1,179 @@
+package diff
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{name: "both empty", a: "", b: "", want: nil},
+		{
+			name: "equal",
+			a:    "a\nb",
+			b:    "a\nb",
+			want: []Line{{OpEqual, "a"}, {OpEqual, "b"}},
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "a\nb",
+			want: []Line{{OpInsert, "a"}, {OpInsert, "b"}},
+		},
+		{
+			name: "to empty",
+			a:    "a\nb",
+			b:    "",
+			want: []Line{{OpDelete, "a"}, {OpDelete, "b"}},
+		},
+		{
+			name: "replaced line",
+			a:    "a\nb\nc",
+			b:    "a\nx\nc",
+			want: []Line{{OpEqual, "a"}, {OpDelete, "b"}, {OpInsert, "x"}, {OpEqual, "c"}},
+		},
+		{
+			name: "inserted in the middle",
+			a:    "a\nc",
+			b:    "a\nb\nc",
+			want: []Line{{OpEqual, "a"}, {OpInsert, "b"}, {OpEqual, "c"}},
+		},
+		{
+			name: "deleted at the end",
+			a:    "a\nb\nc",
+			b:    "a\nb",
+			want: []Line{{OpEqual, "a"}, {OpEqual, "b"}, {OpDelete, "c"}},
+		},
+		{
+			name: "trailing newline is an empty line",
+			a:    "a",
+			b:    "a\n",
+			want: []Line{{OpEqual, "a"}, {OpInsert, ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lines(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Lines() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Lines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLinesShortest checks on random texts that the script turns a into b
+// and has no more edits than the longest common subsequence allows.
+func TestLinesShortest(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	words := []string{"a", "b", "c", "d"}
+
+	randomText := func() string {
+		lines := make([]string, rnd.IntN(40))
+		for i := range lines {
+			lines[i] = words[rnd.IntN(len(words))]
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	for range 500 {
+		a, b := randomText(), randomText()
+		script, err := Lines(a, b)
+		if err != nil {
+			t.Fatalf("Lines(%q, %q) error = %v", a, b, err)
+		}
+
+		var old, new []string
+		edits := 0
+		for _, line := range script {
+			switch line.Op {
+			case OpEqual:
+				old = append(old, line.Text)
+				new = append(new, line.Text)
+			case OpDelete:
+				old = append(old, line.Text)
+				edits++
+			case OpInsert:
+				new = append(new, line.Text)
+				edits++
+			}
+		}
+
+		if !slices.Equal(old, split(a)) || !slices.Equal(new, split(b)) {
+			t.Fatalf("Lines(%q, %q) = %v doesn't turn one into the other", a, b, script)
+		}
+
+		if want := len(split(a)) + len(split(b)) - 2*lcs(split(a), split(b)); edits != want {
+			t.Fatalf("Lines(%q, %q) has %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestLinesTooLarge(t *testing.T) {
+	half := strings.Repeat("line\n", MaxLines/2-1) + "line"
+
+	if _, err := Lines(half, half); err != nil {
+		t.Errorf("Lines() at the limit error = %v", err)
+	}
+
+	if _, err := Lines(half, half+"\nmore"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Lines() over the limit error = %v, want %v", err, ErrTooLarge)
+	}
+}
+
+// TestLinesDisjoint compares texts without a common line at the limit, the worst
+// case for both time and memory.
+func TestLinesDisjoint(t *testing.T) {
+	a := make([]string, MaxLines/2)
+	b := make([]string, MaxLines/2)
+	for i := range a {
+		a[i] = "a" + strconv.Itoa(i)
+		b[i] = "b" + strconv.Itoa(i)
+	}
+
+	script, err := Lines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+
+	if len(script) != len(a)+len(b) {
+		t.Fatalf("Lines() has %d lines, want %d", len(script), len(a)+len(b))
+	}
+
+	for i, line := range script {
+		if want := i >= len(a); (line.Op == OpInsert) != want {
+			t.Fatalf("Lines()[%d] = %v, deletions must go before insertions", i, line)
+		}
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,331 @@
+// Package ical reads and writes iCalendar data (RFC 5545) as components holding
+// properties. Values are kept as they appear in the data, Text and Escape convert
+// TEXT values, Time and DateTime DATE and DATE-TIME ones.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest a content line may be before it is folded.
+const maxLineOctets = 75
+
+var ErrInvalidCalendar = errors.New("invalid icalendar data")
+
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN:Name ... END:Name block, like VCALENDAR, VEVENT or VTODO.
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []Component
+}
+
+// Add appends a property with a raw value, see Escape for TEXT values.
+func (c *Component) Add(name, value string) {
+	c.Properties = append(c.Properties, Property{Name: name, Value: value})
+}
+
+// Get returns the first property called name.
+func (c Component) Get(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return Property{}, false
+}
+
+// All returns every property called name.
+func (c Component) All(name string) []Property {
+	var res []Property
+
+	for _, p := range c.Properties {
+		if p.Name == name {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// Escape turns s into a TEXT value.
+func Escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// Text returns the TEXT value of the property unescaped.
+func (p Property) Text() string {
+	return unescape(p.Value)
+}
+
+// Texts returns the values of a TEXT list, like CATEGORIES, unescaped.
+func (p Property) Texts() []string {
+	var res []string
+	var b strings.Builder
+
+	for i := 0; i < len(p.Value); i++ {
+		switch {
+		case p.Value[i] == '\\' && i+1 < len(p.Value):
+			b.WriteByte('\\')
+			b.WriteByte(p.Value[i+1])
+			i++
+		case p.Value[i] == ',':
+			res = append(res, unescape(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(p.Value[i])
+		}
+	}
+
+	return append(res, unescape(b.String()))
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+// DateTime formats t as a DATE-TIME value in UTC.
+func DateTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// Time parses the DATE or DATE-TIME value of the property. Times with a TZID
+// parameter are in that location, floating times and dates are taken as UTC.
+func (p Property) Time() (time.Time, error) {
+	loc := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: unknown TZID %q", ErrInvalidCalendar, tzid)
+		}
+
+		loc = l
+	}
+
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if len(layout) != len(p.Value) {
+			continue
+		}
+
+		if t, err := time.ParseInLocation(layout, p.Value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s %q is not a date or a date-time", ErrInvalidCalendar, p.Name, p.Value)
+}
+
+// Encode writes the component as folded CRLF terminated content lines.
+func Encode(w io.Writer, c Component) error {
+	bw := bufio.NewWriter(w)
+
+	encode(bw, c)
+
+	return bw.Flush()
+}
+
+func encode(w *bufio.Writer, c Component) {
+	writeLine(w, "BEGIN:"+c.Name)
+
+	for _, p := range c.Properties {
+		var b strings.Builder
+
+		b.WriteString(p.Name)
+		for _, name := range slices.Sorted(maps.Keys(p.Params)) {
+			value := p.Params[name]
+			b.WriteString(";" + name + "=")
+			if strings.ContainsAny(value, ";:,") {
+				value = `"` + value + `"`
+			}
+			b.WriteString(value)
+		}
+		b.WriteString(":" + p.Value)
+
+		writeLine(w, b.String())
+	}
+
+	for _, sub := range c.Components {
+		encode(w, sub)
+	}
+
+	writeLine(w, "END:"+c.Name)
+}
+
+// writeLine folds the line after maxLineOctets, without splitting a UTF-8 sequence.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of a continuation line counts
+		limit = maxLineOctets - 1
+	}
+
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// Parse reads the first top-level component from r, usually a VCALENDAR.
+func Parse(r io.Reader) (Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return Component{}, err
+	}
+
+	var stack []Component
+
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		p, err := parseLine(line)
+		if err != nil {
+			return Component{}, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch p.Name {
+		case "BEGIN":
+			stack = append(stack, Component{Name: strings.ToUpper(p.Value)})
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return Component{}, fmt.Errorf("line %d: %w: unexpected END:%s", i+1, ErrInvalidCalendar, p.Value)
+			}
+
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return c, nil
+			}
+
+			stack[len(stack)-1].Components = append(stack[len(stack)-1].Components, c)
+		default:
+			if len(stack) == 0 {
+				return Component{}, fmt.Errorf("line %d: %w: %s outside of a component", i+1, ErrInvalidCalendar, p.Name)
+			}
+
+			stack[len(stack)-1].Properties = append(stack[len(stack)-1].Properties, p)
+		}
+	}
+
+	return Component{}, fmt.Errorf("%w: no complete component", ErrInvalidCalendar)
+}
+
+// unfold returns the content lines of r with folded lines joined.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// parseLine splits a content line into name, parameters and value. Colons and
+// semicolons inside quoted parameter values don't count.
+func parseLine(line string) (Property, error) {
+	var parts []string
+	inQuotes := false
+	start := 0
+	valueAt := -1
+
+	for i := 0; i < len(line) && valueAt < 0; i++ {
+		switch line[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ';':
+			if !inQuotes {
+				parts = append(parts, line[start:i])
+				start = i + 1
+			}
+		case ':':
+			if !inQuotes {
+				parts = append(parts, line[start:i])
+				valueAt = i + 1
+			}
+		}
+	}
+
+	if valueAt < 0 || parts[0] == "" {
+		return Property{}, fmt.Errorf("%w: %q is not a content line", ErrInvalidCalendar, line)
+	}
+
+	p := Property{
+		Name:  strings.ToUpper(parts[0]),
+		Value: line[valueAt:],
+	}
+
+	for _, param := range parts[1:] {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return Property{}, fmt.Errorf("%w: %q is not a parameter", ErrInvalidCalendar, param)
+		}
+
+		if p.Params == nil {
+			p.Params = make(map[string]string)
+		}
+		p.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,287 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "plain", want: "plain"},
+		{text: `a\b`, want: `a\\b`},
+		{text: "a;b,c", want: `a\;b\,c`},
+		{text: "line\nnext", want: `line\nnext`},
+		{text: "line\r\nnext", want: `line\nnext`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := Escape(tt.text)
+			if got != tt.want {
+				t.Errorf("Escape(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+
+			if back := (Property{Value: got}).Text(); back != strings.ReplaceAll(tt.text, "\r\n", "\n") {
+				t.Errorf("Text() of %q = %q, want %q", got, back, tt.text)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: `a\Nb`, want: "a\nb"},
+		{value: `trailing\`, want: `trailing\`},
+		{value: `a\:b`, want: "a:b"},
+	}
+
+	for _, tt := range tests {
+		if got := (Property{Value: tt.value}).Text(); got != tt.want {
+			t.Errorf("Text() of %q = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTexts(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "work", want: []string{"work"}},
+		{value: "work,home", want: []string{"work", "home"}},
+		{value: `a\,b,c`, want: []string{"a,b", "c"}},
+		{value: `a\\,b`, want: []string{`a\`, "b"}},
+		{value: "", want: []string{""}},
+	}
+
+	for _, tt := range tests {
+		if got := (Property{Value: tt.value}).Texts(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Texts() of %q = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation(): %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		prop    Property
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "utc",
+			prop: Property{Name: "DUE", Value: "20261020T090000Z"},
+			want: time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "tzid",
+			prop: Property{Name: "DUE", Params: map[string]string{"TZID": "Europe/Berlin"}, Value: "20261020T090000"},
+			want: time.Date(2026, time.October, 20, 9, 0, 0, 0, berlin),
+		},
+		{
+			name: "floating",
+			prop: Property{Name: "DUE", Value: "20261020T090000"},
+			want: time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "date",
+			prop: Property{Name: "DTSTART", Params: map[string]string{"VALUE": "DATE"}, Value: "20261020"},
+			want: time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "unknown tzid",
+			prop:    Property{Name: "DUE", Params: map[string]string{"TZID": "Mars/Olympus"}, Value: "20261020T090000"},
+			wantErr: true,
+		},
+		{name: "not a date", prop: Property{Name: "DUE", Value: "tomorrow"}, wantErr: true},
+		{name: "invalid date", prop: Property{Name: "DUE", Value: "20261340"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.prop.Time()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCalendar) {
+					t.Errorf("Time() = %v, %v, want ErrInvalidCalendar", got, err)
+				}
+
+				return
+			}
+
+			if err != nil || !got.Equal(tt.want) || got.Location().String() != tt.want.Location().String() {
+				t.Errorf("Time() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation(): %v", err)
+	}
+
+	if got := DateTime(time.Date(2026, time.October, 20, 11, 0, 0, 0, berlin)); got != "20261020T090000Z" {
+		t.Errorf("DateTime() = %s, want 20261020T090000Z", got)
+	}
+}
+
+func TestEncodeFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "short", value: "short"},
+		{name: "exactly at the limit", value: strings.Repeat("a", maxLineOctets-len("SUMMARY:"))},
+		{name: "one over the limit", value: strings.Repeat("a", maxLineOctets-len("SUMMARY:")+1)},
+		{name: "long", value: strings.Repeat("abcdefghij", 30)},
+		{name: "multibyte", value: strings.Repeat("ä€😀", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			c := Component{Name: "VTODO"}
+			c.Add("SUMMARY", tt.value)
+
+			if err := Encode(&buf, c); err != nil {
+				t.Fatalf("Encode(): %v", err)
+			}
+
+			out := buf.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("Encode() = %q doesn't end with CRLF", out)
+			}
+
+			for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+				if len(line) > maxLineOctets {
+					t.Errorf("line %q has %d octets, want at most %d", line, len(line), maxLineOctets)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %q splits a UTF-8 sequence", line)
+				}
+			}
+
+			parsed, err := Parse(&buf)
+			if err != nil {
+				t.Fatalf("Parse(): %v", err)
+			}
+
+			if p, _ := parsed.Get("SUMMARY"); p.Value != tt.value {
+				t.Errorf("SUMMARY after a round trip = %q, want %q", p.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEncodeParams(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := Component{Name: "VEVENT", Properties: []Property{{
+		Name:   "DTSTART",
+		Params: map[string]string{"VALUE": "DATE-TIME", "TZID": "Europe/Berlin"},
+		Value:  "20261020T090000",
+	}, {
+		Name:   "ATTENDEE",
+		Params: map[string]string{"CN": "Doe, Jane"},
+		Value:  "mailto:jane@example.com",
+	}}}
+
+	if err := Encode(&buf, c); err != nil {
+		t.Fatalf("Encode(): %v", err)
+	}
+
+	want := "BEGIN:VEVENT\r\n" +
+		"DTSTART;TZID=Europe/Berlin;VALUE=DATE-TIME:20261020T090000\r\n" +
+		"ATTENDEE;CN=\"Doe, Jane\":mailto:jane@example.com\r\n" +
+		"END:VEVENT\r\n"
+	if buf.String() != want {
+		t.Errorf("Encode() =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"summary:Pay\r\n" +
+		"  rent\r\n" +
+		"DUE;TZID=\"Europe/Berlin\":20261020T090000\r\n" +
+		"ATTENDEE;CN=\"a:b;c\":mailto:a@example.com\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VEVENT\n" +
+		"SUMMARY:Bare LF\n" +
+		"\tfolded with a tab\n" +
+		"END:VEVENT\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if cal.Name != "VCALENDAR" || len(cal.Components) != 2 {
+		t.Fatalf("Parse() = %+v, want a VCALENDAR with 2 components", cal)
+	}
+
+	todo := cal.Components[0]
+	if p, _ := todo.Get("SUMMARY"); p.Value != "Pay rent" {
+		t.Errorf("SUMMARY = %q, want the unfolded %q", p.Value, "Pay rent")
+	}
+
+	if p, _ := todo.Get("DUE"); p.Params["TZID"] != "Europe/Berlin" || p.Value != "20261020T090000" {
+		t.Errorf("DUE = %+v, want a quoted TZID parameter", p)
+	}
+
+	if p, _ := todo.Get("ATTENDEE"); p.Params["CN"] != "a:b;c" || p.Value != "mailto:a@example.com" {
+		t.Errorf("ATTENDEE = %+v, want colons and semicolons kept inside quotes", p)
+	}
+
+	if p, _ := cal.Components[1].Get("SUMMARY"); p.Value != "Bare LFfolded with a tab" {
+		t.Errorf("SUMMARY = %q, want lines with bare LF unfolded", p.Value)
+	}
+
+	if _, ok := todo.Get("DTSTART"); ok {
+		t.Error("Get() of a missing property = true")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ""},
+		{name: "no END", data: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"},
+		{name: "wrong END", data: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n"},
+		{name: "END without BEGIN", data: "END:VCALENDAR\r\n"},
+		{name: "property outside a component", data: "VERSION:2.0\r\n"},
+		{name: "no colon", data: "BEGIN:VCALENDAR\r\nVERSION\r\nEND:VCALENDAR\r\n"},
+		{name: "no name", data: "BEGIN:VCALENDAR\r\n:2.0\r\nEND:VCALENDAR\r\n"},
+		{name: "parameter without a value", data: "BEGIN:VCALENDAR\r\nDUE;TZID:20261020\r\nEND:VCALENDAR\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := Parse(strings.NewReader(tt.data)); !errors.Is(err, ErrInvalidCalendar) {
+				t.Errorf("Parse() = %+v, %v, want ErrInvalidCalendar", c, err)
+			}
+		})
+	}
+}
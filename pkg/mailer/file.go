@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// File is a mailer for local development: it logs every message
+// and, if dir is set, writes it there as an .eml file.
+type File struct {
+	log  *slog.Logger
+	dir  string
+	from string
+}
+
+func NewFile(log *slog.Logger, dir, from string) *File {
+	return &File{
+		log:  log,
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *File) Send(ctx context.Context, msg Message) error {
+	const op = "mailer.File.Send"
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.log.Info(
+		"mail sent",
+		slog.String("op", op),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	name := filepath.Join(m.dir, strconv.FormatInt(time.Now().UnixNano(), 10)+".eml")
+	if err := os.WriteFile(name, format(m.from, msg), 0o644); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTP returns a mailer that sends through an SMTP server with STARTTLS
+// when the server supports it. Empty username disables authentication.
+func NewSMTP(host string, port int, username, password, from string) *SMTP {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTP{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	const op = "mailer.SMTP.Send"
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, format(m.from, msg))
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,432 @@
+// Package rrule parses and expands recurrence rules (RFC 5545, section 3.3.10).
+// FREQ DAILY, WEEKLY, MONTHLY and YEARLY are supported with INTERVAL, COUNT, UNTIL,
+// BYMONTH, BYMONTHDAY, BYDAY, BYSETPOS and WKST.
+//
+// Occurrences keep the wall clock time of the start in its location, so a rule
+// started at 09:00 in Europe/Berlin stays at 09:00 across DST changes. Dates that
+// don't exist, like the 31st of a 30 day month, are skipped as the RFC requires,
+// BYMONTHDAY=-1 is the last day of every month.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxYears bounds the expansion of rules that run out of occurrences
+// without COUNT or UNTIL, like the 30th of February.
+const maxYears = 1000
+
+var ErrInvalidRule = errors.New("invalid rrule")
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum is a BYDAY value. N picks the nth such weekday of the month or year,
+// counting from the end when negative, 0 picks all of them.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule is a parsed RRULE. Interval is at least 1, Count and Until are zero when absent.
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByMonth    []time.Month
+	ByMonthDay []int
+	ByDay      []WeekdayNum
+	BySetPos   []int
+	WeekStart  time.Weekday
+
+	// untilLocal is set for an UNTIL without Z, its wall clock time is in the location of the start.
+	untilLocal bool
+	// untilDate is set for an UNTIL without time, it includes the whole day.
+	untilDate bool
+}
+
+// Parse parses a rule like "FREQ=MONTHLY;BYDAY=-1FR", the "RRULE:" prefix is optional.
+func Parse(s string) (Rule, error) {
+	r := Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Rule{}, invalid("%q is not a NAME=VALUE pair", part)
+		}
+
+		if seen[name] {
+			return Rule{}, invalid("%s is given twice", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch value {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = value
+			default:
+				err = invalid("FREQ %s is not supported", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parseInt(name, value, 1, 10_000)
+		case "COUNT":
+			r.Count, err = parseInt(name, value, 1, 100_000)
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYMONTH":
+			err = eachValue(name, value, 1, 12, func(n int) { r.ByMonth = append(r.ByMonth, time.Month(n)) })
+		case "BYMONTHDAY":
+			err = eachValue(name, value, -31, 31, func(n int) { r.ByMonthDay = append(r.ByMonthDay, n) })
+		case "BYSETPOS":
+			err = eachValue(name, value, -366, 366, func(n int) { r.BySetPos = append(r.BySetPos, n) })
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				wn, ok := parseWeekdayNum(v)
+				if !ok {
+					return Rule{}, invalid("BYDAY %q is not a weekday like MO, 2TU or -1FR", v)
+				}
+
+				r.ByDay = append(r.ByDay, wn)
+			}
+		case "WKST":
+			wd, ok := weekdays[value]
+			if !ok {
+				err = invalid("WKST %q is not a weekday", value)
+			}
+			r.WeekStart = wd
+		default:
+			err = invalid("%s is not supported", name)
+		}
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return Rule{}, invalid("FREQ is required")
+	case r.Count > 0 && !r.Until.IsZero():
+		return Rule{}, invalid("COUNT and UNTIL can't be used together")
+	case r.Freq == Weekly && len(r.ByMonthDay) > 0:
+		return Rule{}, invalid("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	case len(r.BySetPos) > 0 && len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+		return Rule{}, invalid("BYSETPOS needs BYMONTH, BYMONTHDAY or BYDAY")
+	}
+
+	if r.Freq == Daily || r.Freq == Weekly {
+		for _, wn := range r.ByDay {
+			if wn.N != 0 {
+				return Rule{}, invalid("BYDAY can't be numbered with FREQ=%s", r.Freq)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func invalid(format string, a ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, a...))
+}
+
+func parseInt(name, value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max || n == 0 {
+		return 0, invalid("%s %q must be a number from %d to %d", name, value, min, max)
+	}
+
+	return n, nil
+}
+
+// eachValue calls add with every number of a comma separated list.
+func eachValue(name, value string, min, max int, add func(int)) error {
+	for _, v := range strings.Split(value, ",") {
+		n, err := parseInt(name, v, min, max)
+		if err != nil {
+			return err
+		}
+
+		add(n)
+	}
+
+	return nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, bool) {
+	if len(s) < 2 {
+		return WeekdayNum{}, false
+	}
+
+	wd, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, false
+	}
+
+	wn := WeekdayNum{Weekday: wd}
+	if num := s[:len(s)-2]; num != "" {
+		n, err := strconv.Atoi(num)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, false
+		}
+
+		wn.N = n
+	}
+
+	return wn, true
+}
+
+func (r *Rule) parseUntil(value string) error {
+	var err error
+
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		r.Until, err = time.Parse("20060102T150405Z", value)
+	case strings.Contains(value, "T"):
+		r.Until, err = time.Parse("20060102T150405", value)
+		r.untilLocal = true
+	default:
+		r.Until, err = time.Parse("20060102", value)
+		r.untilDate = true
+	}
+	if err != nil {
+		return invalid("UNTIL %q is not a date or a date-time", value)
+	}
+
+	return nil
+}
+
+// Next returns the first occurrence after after of the rule started at start,
+// and false when the rule has no more occurrences.
+func (r Rule) Next(start, after time.Time) (time.Time, bool) {
+	for t := range r.Occurrences(start) {
+		if t.After(after) {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Occurrences returns the occurrences of the rule started at start in ascending order,
+// in the location of start. As in RFC 5545 start is always the first occurrence and
+// counts towards COUNT, even when it doesn't match the rule.
+func (r Rule) Occurrences(start time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		r := r.withDefaults(start)
+		loc := start.Location()
+		hour, minute, sec := start.Clock()
+		first := civil(start)
+
+		if !yield(start) {
+			return
+		}
+
+		count := 1
+		if count == r.Count {
+			return
+		}
+
+		for i := 0; ; i++ {
+			days := r.period(first, i)
+			if len(days) == 0 || days[0].Year() > min(first.Year()+maxYears, 9999) {
+				return
+			}
+
+			for _, day := range r.setPos(r.filter(days)) {
+				t := wallClock(day, hour, minute, sec, start.Nanosecond(), loc)
+				if !t.After(start) {
+					continue
+				}
+				if r.ends(t) {
+					return
+				}
+
+				if !yield(t) {
+					return
+				}
+
+				count++
+				if count == r.Count {
+					return
+				}
+			}
+		}
+	}
+}
+
+// withDefaults takes the parts a rule leaves out from the start, as RFC 5545 does:
+// a weekly rule repeats on the weekday of the start, a monthly one on its day of
+// the month and a yearly one on its date.
+func (r Rule) withDefaults(start time.Time) Rule {
+	switch {
+	case r.Freq == Weekly && len(r.ByDay) == 0:
+		r.ByDay = []WeekdayNum{{Weekday: start.Weekday()}}
+	case r.Freq == Monthly && len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+		r.ByMonthDay = []int{start.Day()}
+	case r.Freq == Yearly && len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+		r.ByMonthDay = []int{start.Day()}
+		if len(r.ByMonth) == 0 {
+			r.ByMonth = []time.Month{start.Month()}
+		}
+	}
+
+	return r
+}
+
+// wallClock returns the time of day on day in loc. A time in the gap of a DST change
+// is taken with the offset from before the gap, as RFC 5545 does, so 02:30 on the
+// day clocks go from 02:00 to 03:00 is 03:30. time.Date would make it 01:30.
+func wallClock(day time.Time, hour, minute, sec, nsec int, loc *time.Location) time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, sec, nsec, loc)
+	if h, m, _ := t.Clock(); h == hour && m == minute {
+		return t
+	}
+
+	_, offset := t.Zone()
+	wall := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, sec, nsec, time.UTC)
+
+	return wall.Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// civil returns the date of t as midnight UTC, which date arithmetic can't shift across DST changes.
+func civil(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// period returns the days of the ith period of the rule from the one holding first.
+func (r Rule) period(first time.Time, i int) []time.Time {
+	var from time.Time
+	var n int
+
+	switch r.Freq {
+	case Daily:
+		from, n = first.AddDate(0, 0, i*r.Interval), 1
+	case Weekly:
+		weekStart := first.AddDate(0, 0, -((int(first.Weekday()) - int(r.WeekStart) + 7) % 7))
+		from, n = weekStart.AddDate(0, 0, 7*i*r.Interval), 7
+	case Monthly:
+		from = time.Date(first.Year(), first.Month()+time.Month(i*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		n = daysIn(from.Year(), from.Month())
+	case Yearly:
+		from = time.Date(first.Year()+i*r.Interval, time.January, 1, 0, 0, 0, 0, time.UTC)
+		n = time.Date(from.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	}
+
+	days := make([]time.Time, n)
+	for j := range days {
+		days[j] = from.AddDate(0, 0, j)
+	}
+
+	return days
+}
+
+// filter keeps the days that match the BY parts of the rule.
+func (r Rule) filter(days []time.Time) []time.Time {
+	res := make([]time.Time, 0, len(days))
+
+	for _, day := range days {
+		if r.matches(day) {
+			res = append(res, day)
+		}
+	}
+
+	return res
+}
+
+func (r Rule) matches(day time.Time) bool {
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, day.Month()) {
+		return false
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		last := daysIn(day.Year(), day.Month())
+		if !slices.ContainsFunc(r.ByMonthDay, func(n int) bool {
+			return n == day.Day() || n < 0 && last+n+1 == day.Day()
+		}) {
+			return false
+		}
+	}
+
+	if len(r.ByDay) > 0 && !slices.ContainsFunc(r.ByDay, func(wn WeekdayNum) bool {
+		return wn.Weekday == day.Weekday() && (wn.N == 0 || wn.N == r.nth(day, wn.N < 0))
+	}) {
+		return false
+	}
+
+	return true
+}
+
+// nth returns which of its weekday the day is in the month, or in the year for
+// yearly rules without BYMONTH. fromEnd counts backwards from -1.
+func (r Rule) nth(day time.Time, fromEnd bool) int {
+	pos, last := day.Day(), daysIn(day.Year(), day.Month())
+	if r.Freq == Yearly && len(r.ByMonth) == 0 {
+		pos, last = day.YearDay(), time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	}
+
+	if fromEnd {
+		return -((last-pos)/7 + 1)
+	}
+
+	return (pos-1)/7 + 1
+}
+
+// setPos picks the BYSETPOS days of a period, keeping them in order.
+func (r Rule) setPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return days
+	}
+
+	res := make([]time.Time, 0, len(r.BySetPos))
+	for i, day := range days {
+		if slices.Contains(r.BySetPos, i+1) || slices.Contains(r.BySetPos, i-len(days)) {
+			res = append(res, day)
+		}
+	}
+
+	return res
+}
+
+// ends reports whether t is past UNTIL.
+func (r Rule) ends(t time.Time) bool {
+	switch {
+	case r.Until.IsZero():
+		return false
+	case r.untilDate:
+		return civil(t).After(r.Until)
+	case r.untilLocal:
+		u := r.Until
+		return t.After(time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, t.Location()))
+	default:
+		return t.After(r.Until)
+	}
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,334 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+
+	return loc
+}
+
+// take returns the first n occurrences of the rule formatted as RFC 3339.
+func take(t *testing.T, rule string, start time.Time, n int) []string {
+	t.Helper()
+
+	r, err := Parse(rule)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", rule, err)
+	}
+
+	var res []string
+	for o := range r.Occurrences(start) {
+		if len(res) == n {
+			break
+		}
+
+		res = append(res, o.Format(time.RFC3339))
+	}
+
+	return res
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestOccurrences(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		n     int
+		want  []string
+	}{
+		{
+			name:  "daily across spring forward",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2026, time.March, 7, 9, 0, 0, 0, ny),
+			n:     3,
+			want:  []string{"2026-03-07T09:00:00-05:00", "2026-03-08T09:00:00-04:00", "2026-03-09T09:00:00-04:00"},
+		},
+		{
+			name:  "daily across fall back",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2026, time.October, 31, 9, 0, 0, 0, ny),
+			n:     3,
+			want:  []string{"2026-10-31T09:00:00-04:00", "2026-11-01T09:00:00-05:00", "2026-11-02T09:00:00-05:00"},
+		},
+		{
+			name:  "weekly across spring forward",
+			rule:  "FREQ=WEEKLY",
+			start: time.Date(2026, time.October, 19, 18, 30, 0, 0, berlin),
+			n:     3,
+			want:  []string{"2026-10-19T18:30:00+02:00", "2026-10-26T18:30:00+01:00", "2026-11-02T18:30:00+01:00"},
+		},
+		{
+			name:  "daily through the nonexistent hour",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2026, time.March, 7, 2, 30, 0, 0, ny),
+			n:     3,
+			want:  []string{"2026-03-07T02:30:00-05:00", "2026-03-08T03:30:00-04:00", "2026-03-09T02:30:00-04:00"},
+		},
+		{
+			// 02:30 doesn't exist on 2026-03-08, with the offset from before the gap it is 03:30 EDT
+			name:  "start in the nonexistent hour",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2026, time.March, 8, 7, 30, 0, 0, time.UTC).In(ny),
+			n:     2,
+			want:  []string{"2026-03-08T03:30:00-04:00", "2026-03-09T03:30:00-04:00"},
+		},
+		{
+			name:  "daily in the repeated hour",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2026, time.October, 31, 1, 30, 0, 0, ny),
+			n:     2,
+			want:  []string{"2026-10-31T01:30:00-04:00", "2026-11-01T01:30:00-04:00"},
+		},
+		{
+			name:  "31st skips short months",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: time.Date(2027, time.January, 31, 10, 0, 0, 0, time.UTC),
+			n:     4,
+			want:  []string{"2027-01-31T10:00:00Z", "2027-03-31T10:00:00Z", "2027-05-31T10:00:00Z", "2027-07-31T10:00:00Z"},
+		},
+		{
+			name:  "31st without BYMONTHDAY",
+			rule:  "FREQ=MONTHLY",
+			start: time.Date(2027, time.January, 31, 10, 0, 0, 0, time.UTC),
+			n:     2,
+			want:  []string{"2027-01-31T10:00:00Z", "2027-03-31T10:00:00Z"},
+		},
+		{
+			name:  "last day of the month in a leap year",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: time.Date(2028, time.January, 31, 10, 0, 0, 0, time.UTC),
+			n:     4,
+			want:  []string{"2028-01-31T10:00:00Z", "2028-02-29T10:00:00Z", "2028-03-31T10:00:00Z", "2028-04-30T10:00:00Z"},
+		},
+		{
+			name:  "last day of the month in a common year",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: time.Date(2027, time.January, 31, 10, 0, 0, 0, time.UTC),
+			n:     2,
+			want:  []string{"2027-01-31T10:00:00Z", "2027-02-28T10:00:00Z"},
+		},
+		{
+			name:  "29th of February",
+			rule:  "FREQ=YEARLY",
+			start: time.Date(2024, time.February, 29, 8, 0, 0, 0, time.UTC),
+			n:     3,
+			want:  []string{"2024-02-29T08:00:00Z", "2028-02-29T08:00:00Z", "2032-02-29T08:00:00Z"},
+		},
+		{
+			name:  "last friday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: time.Date(2026, time.October, 30, 17, 0, 0, 0, time.UTC),
+			n:     3,
+			want:  []string{"2026-10-30T17:00:00Z", "2026-11-27T17:00:00Z", "2026-12-25T17:00:00Z"},
+		},
+		{
+			name:  "last workday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			start: time.Date(2026, time.October, 30, 17, 0, 0, 0, time.UTC),
+			n:     3,
+			want:  []string{"2026-10-30T17:00:00Z", "2026-11-30T17:00:00Z", "2026-12-31T17:00:00Z"},
+		},
+		{
+			name:  "every other week on two days",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			start: time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC),
+			n:     4,
+			want:  []string{"2026-10-20T09:00:00Z", "2026-10-22T09:00:00Z", "2026-11-03T09:00:00Z", "2026-11-05T09:00:00Z"},
+		},
+		{
+			name:  "count",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC),
+			n:     10,
+			want:  []string{"2026-10-17T09:00:00Z", "2026-10-18T09:00:00Z", "2026-10-19T09:00:00Z"},
+		},
+		{
+			name:  "count of one",
+			rule:  "FREQ=DAILY;COUNT=1",
+			start: time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC),
+			n:     10,
+			want:  []string{"2026-10-17T09:00:00Z"},
+		},
+		{
+			// the start is a saturday, it is the first of the three occurrences all the same
+			name:  "count with a start that doesn't match",
+			rule:  "FREQ=WEEKLY;BYDAY=MO;COUNT=3",
+			start: time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC),
+			n:     10,
+			want:  []string{"2026-10-17T09:00:00Z", "2026-10-19T09:00:00Z", "2026-10-26T09:00:00Z"},
+		},
+		{
+			name:  "until a date includes the day",
+			rule:  "FREQ=DAILY;UNTIL=20261019",
+			start: time.Date(2026, time.October, 17, 23, 0, 0, 0, time.UTC),
+			n:     10,
+			want:  []string{"2026-10-17T23:00:00Z", "2026-10-18T23:00:00Z", "2026-10-19T23:00:00Z"},
+		},
+		{
+			name:  "until a utc date-time",
+			rule:  "FREQ=DAILY;UNTIL=20261019T125959Z",
+			start: time.Date(2026, time.October, 17, 9, 0, 0, 0, ny),
+			n:     10,
+			want:  []string{"2026-10-17T09:00:00-04:00", "2026-10-18T09:00:00-04:00"},
+		},
+		{
+			name:  "until a utc date-time at an occurrence",
+			rule:  "FREQ=DAILY;UNTIL=20261019T130000Z",
+			start: time.Date(2026, time.October, 17, 13, 0, 0, 0, time.UTC),
+			n:     10,
+			want:  []string{"2026-10-17T13:00:00Z", "2026-10-18T13:00:00Z", "2026-10-19T13:00:00Z"},
+		},
+		{
+			name:  "until a floating date-time",
+			rule:  "FREQ=DAILY;UNTIL=20261018T090000",
+			start: time.Date(2026, time.October, 17, 9, 0, 0, 0, ny),
+			n:     10,
+			want:  []string{"2026-10-17T09:00:00-04:00", "2026-10-18T09:00:00-04:00"},
+		},
+		{
+			name:  "rule that never matches again",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start: time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC),
+			n:     10,
+			want:  []string{"2026-10-17T09:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := take(t, tt.rule, tt.start, tt.n); !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	start := time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		rule   string
+		after  time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "next day",
+			rule:   "FREQ=DAILY",
+			after:  start,
+			want:   start.AddDate(0, 0, 1),
+			wantOK: true,
+		},
+		{
+			name:   "between occurrences",
+			rule:   "FREQ=WEEKLY",
+			after:  start.AddDate(0, 0, 3),
+			want:   start.AddDate(0, 0, 7),
+			wantOK: true,
+		},
+		{
+			name:   "count exhausted",
+			rule:   "FREQ=DAILY;COUNT=2",
+			after:  start.AddDate(0, 0, 1),
+			wantOK: false,
+		},
+		{
+			name:   "last of the count",
+			rule:   "FREQ=WEEKLY;BYDAY=MO;COUNT=2",
+			after:  start,
+			want:   time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "past until",
+			rule:   "FREQ=DAILY;UNTIL=20261018",
+			after:  start.AddDate(0, 0, 1),
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+
+			got, ok := r.Next(start, tt.after)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule    string
+		wantErr bool
+	}{
+		{rule: "FREQ=DAILY"},
+		{rule: "RRULE:FREQ=WEEKLY;BYDAY=MO,WE;WKST=SU"},
+		{rule: "freq=monthly;byday=2tu"},
+		{rule: "FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU"},
+		{rule: "FREQ=DAILY;UNTIL=20261231T235959Z"},
+		{rule: "", wantErr: true},
+		{rule: "INTERVAL=2", wantErr: true},
+		{rule: "FREQ=HOURLY", wantErr: true},
+		{rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		{rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=-1", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=2;UNTIL=20261231", wantErr: true},
+		{rule: "FREQ=DAILY;UNTIL=tomorrow", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=0", wantErr: true},
+		{rule: "FREQ=YEARLY;BYMONTH=13", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYSETPOS=1", wantErr: true},
+		{rule: "FREQ=DAILY;WKST=XX", wantErr: true},
+		{rule: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := Parse(tt.rule)
+			if tt.wantErr && !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("Parse(%q) = %v, want ErrInvalidRule", tt.rule, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Parse(%q) = %v, want no error", tt.rule, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package totp
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors, "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCode checks the RFC 6238 appendix B vectors, cut to the last 6 digits.
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatalf("Code(): %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Code() at %d = %s, want %s", tt.unix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeSecretFormats(t *testing.T) {
+	for _, secret := range []string{"gezdgnbvgy3tqojqgezdgnbvgy3tqojq", rfcSecret + "===="} {
+		got, err := Code(secret, Step(time.Unix(59, 0)))
+		if err != nil || got != "287082" {
+			t.Errorf("Code(%q) = %s, %v, want 287082", secret, got, err)
+		}
+	}
+
+	if _, err := Code("not base32!", 1); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Code() of an invalid secret = %v, want ErrInvalidSecret", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", code: "050471", skew: 0, wantStep: Step(now), wantOK: true},
+		{name: "previous step within skew", code: "081804", skew: 1, wantStep: Step(now) - 1, wantOK: true},
+		{name: "previous step without skew", code: "081804", skew: 0},
+		{name: "wrong code", code: "000000", skew: 1},
+		{name: "too short", code: "50471", skew: 1},
+		{name: "too long", code: "0504710", skew: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := Validate(rfcSecret, tt.code, now, tt.skew)
+			if err != nil {
+				t.Fatalf("Validate(): %v", err)
+			}
+
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate(%s) = %d, %v, want %d, %v", tt.code, step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret(): %v", err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != secretBytes {
+		t.Errorf("GenerateSecret() = %q, want %d base32 bytes", secret, secretBytes)
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	u, err := url.Parse(ProvisioningURI("todo", "alice", rfcSecret))
+	if err != nil {
+		t.Fatalf("url.Parse(): %v", err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/todo:alice" {
+		t.Errorf("ProvisioningURI() = %s, want otpauth://totp/todo:alice", u)
+	}
+
+	query := u.Query()
+	for name, want := range map[string]string{"secret": rfcSecret, "issuer": "todo", "digits": "6", "period": "30"} {
+		if got := query.Get(name); got != want {
+			t.Errorf("ProvisioningURI() %s = %q, want %q", name, got, want)
+		}
+	}
+}